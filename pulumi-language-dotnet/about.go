@@ -0,0 +1,165 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+var pulumiPackageVersionRegexp = regexp.MustCompile(
+	`<PackageReference\s+Include\s*=\s*"Pulumi"\s+Version\s*=\s*"([^"]*)"`)
+
+// dotnetOutput runs a dotnet command that prints information, and returns what it printed.
+func dotnetOutput(ctx context.Context, dotnetExec, dir string, args ...string) (string, error) {
+	cmd, release := dotnetCommand(ctx, dotnetExec, args...)
+	defer release()
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to execute '%s %s'", dotnetExec, strings.Join(args, " "))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// aboutMetadata describes the .NET environment of the program in programDirectory, built from projectFile if it
+// could be found, for `pulumi about` and bug reports.  Anything that can't be determined is left out.
+func aboutMetadata(ctx context.Context, dotnetExec, programDirectory, projectFile string) map[string]string {
+	metadata := map[string]string{}
+	for key, arg := range map[string]string{"sdks": "--list-sdks", "runtimes": "--list-runtimes"} {
+		out, err := dotnetOutput(ctx, dotnetExec, programDirectory, arg)
+		if err != nil {
+			logging.V(5).Infof("About: %v", err)
+			continue
+		}
+		metadata[key] = out
+	}
+
+	if path, sdk, err := findGlobalJSON(programDirectory); err != nil {
+		logging.V(5).Infof("About: %v", err)
+	} else if path != "" {
+		metadata["globalJson"] = path
+		if sdk != "" {
+			metadata["globalJsonSdk"] = sdk
+		}
+	}
+
+	if projectFile == "" {
+		return metadata
+	}
+	metadata["project"] = projectFile
+	if frameworks := projectTargetFrameworks(projectFile); len(frameworks) > 0 {
+		metadata["targetFrameworks"] = strings.Join(frameworks, ";")
+	}
+	if version := pulumiSdkVersion(projectFile); version != "" {
+		metadata["pulumiSdk"] = version
+	}
+	return metadata
+}
+
+// findGlobalJSON returns the `global.json` that selects the SDK for dir, and a description of the SDK it pins, if
+// any.
+func findGlobalJSON(dir string) (string, string, error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		path := filepath.Join(d, "global.json")
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var globalJSON struct {
+				SDK struct {
+					Version     string `json:"version"`
+					RollForward string `json:"rollForward"`
+				} `json:"sdk"`
+			}
+			if err := json.Unmarshal(data, &globalJSON); err != nil {
+				return path, "", errors.Wrapf(err, "could not parse %s", path)
+			}
+			sdk := globalJSON.SDK.Version
+			if sdk != "" && globalJSON.SDK.RollForward != "" {
+				sdk += " (rollForward: " + globalJSON.SDK.RollForward + ")"
+			}
+			return path, sdk, nil
+		} else if !os.IsNotExist(err) {
+			return "", "", err
+		}
+		if parent := filepath.Dir(d); parent == d {
+			return "", "", nil
+		}
+	}
+}
+
+// pulumiSdkVersion returns the version of the Pulumi package projectFile resolves to, or the version it asks for if
+// it hasn't been restored.
+func pulumiSdkVersion(projectFile string) string {
+	if isFileBasedProgram(projectFile) {
+		return fileProgramPulumiSdkVersion(projectFile)
+	}
+	assets, _, err := readProjectAssets(projectFile)
+	if err == nil {
+		var versions []string
+		for key, library := range assets.Libraries {
+			id, version, ok := strings.Cut(key, "/")
+			if ok && library.Type == "package" && strings.EqualFold(id, "Pulumi") {
+				versions = append(versions, version)
+			}
+		}
+		if len(versions) > 0 {
+			sort.Strings(versions)
+			return strings.Join(versions, ", ")
+		}
+	} else {
+		logging.V(5).Infof("About: %v", err)
+	}
+
+	data, err := os.ReadFile(projectFile)
+	if err != nil {
+		return ""
+	}
+	if m := pulumiPackageVersionRegexp.FindSubmatch(data); m != nil {
+		return string(m[1]) + " (requested, not restored)"
+	}
+	return ""
+}
+
+// fileProgramPulumiSdkVersion is pulumiSdkVersion for a file-based program, which asks for Pulumi with a `#:package`
+// directive.
+func fileProgramPulumiSdkVersion(program string) string {
+	packageDirs, err := resolvePackageFolders(program)
+	if err != nil {
+		logging.V(5).Infof("About: %v", err)
+	}
+	list, err := fileProgramPackages(program, packageDirs)
+	if err != nil {
+		logging.V(5).Infof("About: %v", err)
+		return ""
+	}
+	for _, pkg := range list.Projects[0].Frameworks[0].Packages {
+		if !strings.EqualFold(pkg.ID, "Pulumi") {
+			continue
+		}
+		if restoredPackageVersion(packageDirs, pkg.ID, pkg.RequestedVersion) == "" {
+			return pkg.RequestedVersion + " (requested, not restored)"
+		}
+		return pkg.ResolvedVersion
+	}
+	return ""
+}
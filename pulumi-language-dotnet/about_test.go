@@ -0,0 +1,135 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFindGlobalJSON(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	program := filepath.Join(root, "program")
+	require.NoError(t, os.MkdirAll(program, 0o700))
+
+	path, _, err := findGlobalJSON(program)
+	require.NoError(t, err)
+	// There may be one above the temporary directory, but not in it.
+	assert.NotContains(t, path, root)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "global.json"),
+		[]byte(`{"sdk": {"version": "8.0.100", "rollForward": "latestFeature"}}`), 0o600))
+	path, sdk, err := findGlobalJSON(program)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "global.json"), path)
+	assert.Equal(t, "8.0.100 (rollForward: latestFeature)", sdk)
+
+	// The closest one wins, even if it doesn't pin the SDK.
+	require.NoError(t, os.WriteFile(filepath.Join(program, "global.json"), []byte(`{"msbuild-sdks": {}}`), 0o600))
+	path, sdk, err = findGlobalJSON(program)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(program, "global.json"), path)
+	assert.Equal(t, "", sdk)
+}
+
+func TestPulumiSdkVersion(t *testing.T) {
+	t.Parallel()
+
+	projectDir, _ := writeTestAssets(t, nil)
+	projectFile := filepath.Join(projectDir, "Infra.csproj")
+	assert.Equal(t, "3.60.0", pulumiSdkVersion(projectFile))
+
+	// Without a restore, the requested version is all there is to go on.
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "obj", "project.assets.json")))
+	assert.Equal(t, "", pulumiSdkVersion(projectFile))
+	require.NoError(t, os.WriteFile(projectFile, []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <PackageReference Include="Pulumi" Version="3.*" />
+  </ItemGroup>
+</Project>`), 0o600))
+	assert.Equal(t, "3.* (requested, not restored)", pulumiSdkVersion(projectFile))
+}
+
+func TestAbout(t *testing.T) {
+	t.Parallel()
+
+	dotnet, err := exec.LookPath("dotnet")
+	if err != nil {
+		t.Skip("dotnet is not installed")
+	}
+
+	program := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(program, "Infra.csproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net6.0;net8.0</TargetFrameworks>
+  </PropertyGroup>
+</Project>`), 0o600))
+	options, err := structpb.NewStruct(map[string]interface{}{"use-executor": dotnet})
+	require.NoError(t, err)
+
+	host := &dotnetLanguageHost{}
+	resp, err := host.About(context.Background(), &pulumirpc.AboutRequest{
+		Info: &pulumirpc.ProgramInfo{
+			RootDirectory:    program,
+			ProgramDirectory: program,
+			EntryPoint:       ".",
+			Options:          options,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dotnet, resp.Executable)
+	assert.NotEmpty(t, resp.Version)
+	assert.Contains(t, resp.Metadata["sdks"], resp.Version)
+	assert.Contains(t, resp.Metadata["runtimes"], "Microsoft.NETCore.App")
+	assert.Equal(t, filepath.Join(program, "Infra.csproj"), resp.Metadata["project"])
+	assert.Equal(t, "net6.0;net8.0", resp.Metadata["targetFrameworks"])
+}
+
+func TestAboutInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	dotnet, err := exec.LookPath("dotnet")
+	if err != nil {
+		t.Skip("dotnet is not installed")
+	}
+
+	program := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(program, "Infra.csproj"), []byte("<Project />"), 0o600))
+	options, err := structpb.NewStruct(map[string]interface{}{"publish": "fast"})
+	require.NoError(t, err)
+
+	host := &dotnetLanguageHost{}
+	resp, err := host.About(context.Background(), &pulumirpc.AboutRequest{
+		Info: &pulumirpc.ProgramInfo{
+			RootDirectory:    program,
+			ProgramDirectory: program,
+			EntryPoint:       ".",
+			Options:          options,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dotnet, resp.Executable)
+	assert.Equal(t, filepath.Join(program, "Infra.csproj"), resp.Metadata["project"])
+}
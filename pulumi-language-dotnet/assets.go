@@ -0,0 +1,290 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// projectAssets is the subset of `obj/project.assets.json` that NuGet writes on restore that we care about.
+type projectAssets struct {
+	Version int `json:"version"`
+	// Targets maps a target framework (optionally suffixed with `/<rid>`) to the libraries resolved for it, keyed by
+	// `<id>/<version>`.
+	Targets map[string]map[string]struct {
+		Type string `json:"type"`
+	} `json:"targets"`
+	// Libraries maps `<id>/<version>` to where the library lives relative to a package folder.
+	Libraries map[string]struct {
+		Type string `json:"type"`
+		Path string `json:"path"`
+	} `json:"libraries"`
+	// PackageFolders lists the global packages folder and any fallback folders.
+	PackageFolders map[string]struct{} `json:"packageFolders"`
+	Project        struct {
+		Restore struct {
+			ProjectPath     string   `json:"projectPath"`
+			PackagesPath    string   `json:"packagesPath"`
+			ConfigFilePaths []string `json:"configFilePaths"`
+			Frameworks      map[string]struct {
+				ProjectReferences map[string]struct {
+					ProjectPath string `json:"projectPath"`
+				} `json:"projectReferences"`
+			} `json:"frameworks"`
+		} `json:"restore"`
+		Frameworks map[string]struct {
+			Dependencies map[string]struct {
+				Target  string `json:"target"`
+				Version string `json:"version"`
+			} `json:"dependencies"`
+		} `json:"frameworks"`
+	} `json:"project"`
+	Logs []struct {
+		Code    string `json:"code"`
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	} `json:"logs"`
+}
+
+// restoreInputFiles are the files in the project directory hierarchy that, when changed, mean NuGet has to restore
+// again.
+var restoreInputFiles = []string{
+	"Directory.Build.props",
+	"Directory.Build.targets",
+	"Directory.Packages.props",
+	"NuGet.Config",
+	"nuget.config",
+	"NuGet.config",
+	"packages.lock.json",
+}
+
+// readProjectAssets reads the NuGet assets file for the given project, which may either be a project file or a
+// directory containing exactly one project file, and returns it along with the resolved project file.  It returns an
+// error describing why the assets can't be used if the file is missing, from a failed restore, or older than any of
+// the inputs to the restore.
+func readProjectAssets(project string) (*projectAssets, string, error) {
+	projectFile, err := findProjectFile(project)
+	if err != nil {
+		return nil, "", err
+	}
+	if isFileBasedProgram(projectFile) {
+		// Their assets are restored to a directory of their own, not next to the file.
+		return nil, "", errors.Errorf("%s is a file-based program, which has no assets file next to it", projectFile)
+	}
+
+	assetsPath := filepath.Join(filepath.Dir(projectFile), "obj", "project.assets.json")
+	assetsInfo, err := os.Stat(assetsPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(assetsPath)
+	if err != nil {
+		return nil, "", err
+	}
+	var assets projectAssets
+	if err := json.Unmarshal(data, &assets); err != nil {
+		return nil, "", errors.Wrapf(err, "could not parse %s", assetsPath)
+	}
+	if assets.Version != 3 {
+		return nil, "", errors.Errorf("unsupported assets file version %d in %s", assets.Version, assetsPath)
+	}
+
+	for _, log := range assets.Logs {
+		if strings.EqualFold(log.Level, "error") {
+			return nil, "", errors.Errorf("%s is from a failed restore: %s: %s", assetsPath, log.Code, log.Message)
+		}
+	}
+
+	// The assets file might have been copied along with a project that has since moved.
+	if !sameFile(assets.Project.Restore.ProjectPath, projectFile) {
+		return nil, "", errors.Errorf("%s was restored for %s, not %s",
+			assetsPath, assets.Project.Restore.ProjectPath, projectFile)
+	}
+
+	inputs := []string{projectFile}
+	inputs = append(inputs, assets.Project.Restore.ConfigFilePaths...)
+	for _, framework := range assets.Project.Restore.Frameworks {
+		for _, ref := range framework.ProjectReferences {
+			inputs = append(inputs, ref.ProjectPath)
+		}
+	}
+	for dir := filepath.Dir(projectFile); ; dir = filepath.Dir(dir) {
+		for _, name := range restoreInputFiles {
+			inputs = append(inputs, filepath.Join(dir, name))
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, "", err
+		}
+		if info.ModTime().After(assetsInfo.ModTime()) {
+			return nil, "", errors.Errorf("%s is older than %s", assetsPath, input)
+		}
+	}
+
+	// A restore can be up to date but the packages since removed from the cache, e.g. by `dotnet nuget locals
+	// all --clear`.
+	folders := assets.packageFolders()
+	if len(folders) == 0 {
+		return nil, "", errors.Errorf("%s does not list any package folders", assetsPath)
+	}
+	for key, library := range assets.Libraries {
+		if library.Type != "package" {
+			continue
+		}
+		found := false
+		for _, folder := range folders {
+			if _, err := os.Stat(filepath.Join(folder, library.Path)); err == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, "", errors.Errorf("package %s from %s is not in any package folder", key, assetsPath)
+		}
+	}
+
+	return &assets, projectFile, nil
+}
+
+// packageFolders returns the package folders the assets were restored to, global packages folder first.
+func (assets *projectAssets) packageFolders() []string {
+	folders := make([]string, 0, len(assets.PackageFolders))
+	for folder := range assets.PackageFolders {
+		folders = append(folders, filepath.Clean(folder))
+	}
+	// JSON objects are unordered once decoded, but the restore records the global packages folder separately.
+	global := filepath.Clean(assets.Project.Restore.PackagesPath)
+	sort.SliceStable(folders, func(i, j int) bool {
+		if folders[i] == global || folders[j] == global {
+			return folders[i] == global
+		}
+		return folders[i] < folders[j]
+	})
+	return folders
+}
+
+// packageList converts the restored targets into the same shape `dotnet list package --include-transitive` reports.
+func (assets *projectAssets) packageList(projectFile string) *packageList {
+	project := projectPackages{Path: projectFile}
+
+	frameworks := make([]string, 0, len(assets.Targets))
+	for target := range assets.Targets {
+		// Runtime specific targets repeat the packages of their framework.
+		if !strings.Contains(target, "/") {
+			frameworks = append(frameworks, target)
+		}
+	}
+	sort.Strings(frameworks)
+
+	for _, name := range frameworks {
+		topLevel := map[string]string{}
+		if f, ok := assets.Project.Frameworks[name]; ok {
+			for id, dep := range f.Dependencies {
+				topLevel[strings.ToLower(id)] = dep.Version
+			}
+		}
+
+		framework := frameworkPackages{Framework: name}
+		keys := make([]string, 0, len(assets.Targets[name]))
+		for key := range assets.Targets[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if assets.Targets[name][key].Type != "package" {
+				continue
+			}
+			id, version, ok := strings.Cut(key, "/")
+			if !ok {
+				continue
+			}
+			requested, isTopLevel := topLevel[strings.ToLower(id)]
+			framework.Packages = append(framework.Packages, packageReference{
+				ID:               id,
+				RequestedVersion: requested,
+				ResolvedVersion:  version,
+				Transitive:       !isTopLevel,
+			})
+		}
+		project.Frameworks = append(project.Frameworks, framework)
+	}
+
+	return &packageList{Projects: []projectPackages{project}}
+}
+
+// findProjectFile returns the project file for a project path that is either a project file itself or a directory
+// containing exactly one project file.  A file-based program is its own project file.
+func findProjectFile(project string) (string, error) {
+	info, err := os.Stat(project)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		if !isProjectFile(project) && !isFileBasedProgram(project) {
+			return "", errors.Errorf("%s is not a project file", project)
+		}
+		return filepath.Abs(project)
+	}
+
+	entries, err := os.ReadDir(project)
+	if err != nil {
+		return "", err
+	}
+	var found []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isProjectFile(entry.Name()) {
+			found = append(found, entry.Name())
+		}
+	}
+	if len(found) != 1 {
+		return "", errors.Errorf("expected exactly one project file in %s, found %d", project, len(found))
+	}
+	return filepath.Abs(filepath.Join(project, found[0]))
+}
+
+func isProjectFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".csproj", ".fsproj", ".vbproj":
+		return true
+	default:
+		return false
+	}
+}
+
+func sameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
@@ -0,0 +1,196 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestAssets lays out a restored project with a `Pulumi` and a `Pulumi.Aws` package reference and returns the
+// project directory and package folder.
+func writeTestAssets(t *testing.T, mutate func(assets map[string]any)) (string, string) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "program")
+	packageDir := filepath.Join(root, "packages")
+	projectFile := filepath.Join(projectDir, "Infra.csproj")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "obj"), 0o700))
+	require.NoError(t, os.WriteFile(projectFile, []byte("<Project Sdk=\"Microsoft.NET.Sdk\" />"), 0o600))
+	for _, dir := range []string{"pulumi/3.60.0", "pulumi.aws/6.0.0", "google.protobuf/3.10.0"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(packageDir, dir), 0o700))
+	}
+
+	assets := map[string]any{
+		"version": 3,
+		"targets": map[string]any{
+			"net8.0": map[string]any{
+				"Google.Protobuf/3.10.0": map[string]any{"type": "package"},
+				"Pulumi/3.60.0":          map[string]any{"type": "package"},
+				"Pulumi.Aws/6.0.0":       map[string]any{"type": "package"},
+				"Components/1.0.0":       map[string]any{"type": "project"},
+			},
+			"net8.0/linux-x64": map[string]any{
+				"Pulumi/3.60.0": map[string]any{"type": "package"},
+			},
+		},
+		"libraries": map[string]any{
+			"Google.Protobuf/3.10.0": map[string]any{"type": "package", "path": "google.protobuf/3.10.0"},
+			"Pulumi/3.60.0":          map[string]any{"type": "package", "path": "pulumi/3.60.0"},
+			"Pulumi.Aws/6.0.0":       map[string]any{"type": "package", "path": "pulumi.aws/6.0.0"},
+			"Components/1.0.0":       map[string]any{"type": "project", "path": "../Components/Components.csproj"},
+		},
+		"packageFolders": map[string]any{
+			packageDir + string(filepath.Separator): map[string]any{},
+			filepath.Join(root, "fallback"):         map[string]any{},
+		},
+		"project": map[string]any{
+			"restore": map[string]any{
+				"projectPath":  projectFile,
+				"packagesPath": packageDir + string(filepath.Separator),
+			},
+			"frameworks": map[string]any{
+				"net8.0": map[string]any{
+					"dependencies": map[string]any{
+						"Pulumi":     map[string]any{"target": "Package", "version": "[3.60.0, )"},
+						"Pulumi.Aws": map[string]any{"target": "Package", "version": "[6.0.0, )"},
+					},
+				},
+			},
+		},
+	}
+	if mutate != nil {
+		mutate(assets)
+	}
+
+	data, err := json.Marshal(assets)
+	require.NoError(t, err)
+	assetsPath := filepath.Join(projectDir, "obj", "project.assets.json")
+	require.NoError(t, os.WriteFile(assetsPath, data, 0o600))
+
+	// Make sure the restore looks newer than the project file.
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(assetsPath, later, later))
+
+	return projectDir, packageDir
+}
+
+func TestReadProjectAssets(t *testing.T) {
+	t.Parallel()
+
+	projectDir, packageDir := writeTestAssets(t, nil)
+
+	for _, project := range []string{projectDir, filepath.Join(projectDir, "Infra.csproj")} {
+		assets, projectFile, err := readProjectAssets(project)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(projectDir, "Infra.csproj"), projectFile)
+		assert.Equal(t, filepath.Clean(packageDir), assets.packageFolders()[0])
+		assert.Equal(t, &packageList{
+			Projects: []projectPackages{{
+				Path: projectFile,
+				Frameworks: []frameworkPackages{{
+					Framework: "net8.0",
+					Packages: []packageReference{
+						{ID: "Google.Protobuf", ResolvedVersion: "3.10.0", Transitive: true},
+						{ID: "Pulumi.Aws", RequestedVersion: "[6.0.0, )", ResolvedVersion: "6.0.0"},
+						{ID: "Pulumi", RequestedVersion: "[3.60.0, )", ResolvedVersion: "3.60.0"},
+					},
+				}},
+			}},
+		}, assets.packageList(projectFile))
+	}
+}
+
+func TestReadProjectAssetsUnusable(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		projectDir, _ := writeTestAssets(t, nil)
+		require.NoError(t, os.Remove(filepath.Join(projectDir, "obj", "project.assets.json")))
+		_, _, err := readProjectAssets(projectDir)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("project changed", func(t *testing.T) {
+		t.Parallel()
+
+		projectDir, _ := writeTestAssets(t, nil)
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(projectDir, "Infra.csproj"), later, later))
+		_, _, err := readProjectAssets(projectDir)
+		assert.ErrorContains(t, err, "is older than")
+	})
+
+	t.Run("central package versions changed", func(t *testing.T) {
+		t.Parallel()
+
+		projectDir, _ := writeTestAssets(t, nil)
+		props := filepath.Join(filepath.Dir(projectDir), "Directory.Packages.props")
+		require.NoError(t, os.WriteFile(props, []byte("<Project />"), 0o600))
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(props, later, later))
+		_, _, err := readProjectAssets(projectDir)
+		assert.ErrorContains(t, err, "Directory.Packages.props")
+	})
+
+	t.Run("failed restore", func(t *testing.T) {
+		t.Parallel()
+
+		projectDir, _ := writeTestAssets(t, func(assets map[string]any) {
+			assets["logs"] = []any{map[string]any{
+				"code": "NU1301", "level": "Error", "message": "Unable to load the service index",
+			}}
+		})
+		_, _, err := readProjectAssets(projectDir)
+		assert.ErrorContains(t, err, "failed restore: NU1301")
+	})
+
+	t.Run("restored for another project", func(t *testing.T) {
+		t.Parallel()
+
+		projectDir, _ := writeTestAssets(t, func(assets map[string]any) {
+			restore := assets["project"].(map[string]any)["restore"].(map[string]any)
+			restore["projectPath"] = "/elsewhere/Infra.csproj"
+		})
+		_, _, err := readProjectAssets(projectDir)
+		assert.ErrorContains(t, err, "was restored for /elsewhere/Infra.csproj")
+	})
+
+	t.Run("package removed from cache", func(t *testing.T) {
+		t.Parallel()
+
+		projectDir, packageDir := writeTestAssets(t, nil)
+		require.NoError(t, os.RemoveAll(filepath.Join(packageDir, "pulumi.aws")))
+		_, _, err := readProjectAssets(projectDir)
+		assert.ErrorContains(t, err, "package Pulumi.Aws/6.0.0")
+	})
+
+	t.Run("several project files", func(t *testing.T) {
+		t.Parallel()
+
+		projectDir, _ := writeTestAssets(t, nil)
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, "Tests.fsproj"), []byte("<Project />"), 0o600))
+		_, _, err := readProjectAssets(projectDir)
+		assert.ErrorContains(t, err, "expected exactly one project file")
+	})
+}
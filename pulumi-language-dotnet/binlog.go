@@ -0,0 +1,166 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// binlogEnvVar turns on MSBuild binary logs without changing Pulumi.yaml, like the `binlog` option.  It is either a
+// directory to keep them in, or true for the default one.
+const binlogEnvVar = "PULUMI_DOTNET_BINLOG"
+
+// parseBinlogOption returns the directory to keep binary logs in from the `binlog` option, or from binlogEnvVar if
+// the option isn't set, or "" if there are to be none.  By default they go in the user's cache directory.  Relative
+// directories are relative to root, or to the working directory for the environment variable.
+func parseBinlogOption(root string, options map[string]interface{}) (string, error) {
+	var dir string
+	if value, ok := options["binlog"]; ok {
+		switch value := value.(type) {
+		case bool:
+			if !value {
+				return "", nil
+			}
+		case string:
+			dir = value
+			if dir != "" && !filepath.IsAbs(dir) {
+				dir = filepath.Join(root, dir)
+			}
+		default:
+			return "", errors.New("binlog option must be a bool or a directory")
+		}
+	} else {
+		value := os.Getenv(binlogEnvVar)
+		if value == "" {
+			return "", nil
+		}
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			if !enabled {
+				return "", nil
+			}
+		} else {
+			dir = value
+		}
+	}
+
+	if dir == "" {
+		// Binary logs record the environment of the build, so they go somewhere of the user's own.
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", errors.Wrap(err, "could not find a directory for binary logs, set the binlog option to one")
+		}
+		dir = filepath.Join(cacheDir, "pulumi-dotnet", "binlogs")
+	}
+	return filepath.Abs(dir)
+}
+
+// binlogs writes the MSBuild binary logs of the builds in one operation, such as a `pulumi preview` running the
+// program, to a directory of their own.
+type binlogs struct {
+	// The directory the operation's directory goes in.
+	base      string
+	operation string
+	// Tells the user where a binary log was written, or why none are.
+	report func(severity pulumirpc.LogSeverity, message string)
+
+	mu    sync.Mutex
+	dir   string
+	count int
+	// Set once the directory couldn't be made, after which no more logs are attempted.
+	failed bool
+}
+
+type binlogsKey struct{}
+
+// withBinlogs returns a context in which every MSBuild command run with dotnetCommand writes a binary log, if the
+// options ask for them, and w is told where each one is, or why none are.
+func (opts dotnetOptions) withBinlogs(ctx context.Context, operation string, w io.Writer) context.Context {
+	return opts.withBinlogsReport(ctx, operation, func(_ pulumirpc.LogSeverity, message string) {
+		fmt.Fprintln(w, message)
+	})
+}
+
+// withEngineBinlogs is withBinlogs for operations that tell the user through the engine's log.
+func (opts dotnetOptions) withEngineBinlogs(
+	ctx context.Context, operation string, engineClient pulumirpc.EngineClient,
+) context.Context {
+	return opts.withBinlogsReport(ctx, operation, func(severity pulumirpc.LogSeverity, message string) {
+		if engineClient == nil {
+			logging.V(3).Info(message)
+			return
+		}
+		_, err := engineClient.Log(ctx, &pulumirpc.LogRequest{Severity: severity, Message: message})
+		if err != nil {
+			logging.V(5).Infof("could not tell the engine %q: %v", message, err)
+		}
+	})
+}
+
+func (opts dotnetOptions) withBinlogsReport(
+	ctx context.Context, operation string, report func(severity pulumirpc.LogSeverity, message string),
+) context.Context {
+	if opts.binlog == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, binlogsKey{}, &binlogs{base: opts.binlog, operation: operation, report: report})
+}
+
+// binlogPath returns where the MSBuild command args run with ctx should write its binary log, or "" if it shouldn't.
+func binlogPath(ctx context.Context, args []string) string {
+	b, ok := ctx.Value(binlogsKey{}).(*binlogs)
+	if !ok || !takesMSBuildSwitches(args) {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failed {
+		return ""
+	}
+	if b.dir == "" {
+		// Made on the first build, so that operations without any don't leave empty directories behind.
+		err := os.MkdirAll(b.base, 0o700)
+		if err == nil {
+			b.dir, err = os.MkdirTemp(b.base, b.operation+"-"+time.Now().Format("20060102-150405")+"-")
+		}
+		if err != nil {
+			b.failed = true
+			b.report(pulumirpc.LogSeverity_WARNING, "not writing MSBuild binary logs: "+err.Error())
+			return ""
+		}
+	}
+	b.count++
+	return filepath.Join(b.dir, strconv.Itoa(b.count)+"-"+args[0]+".binlog")
+}
+
+// reportBinlog tells the user about the binary log at path, if the command wrote one.
+func reportBinlog(ctx context.Context, path string) {
+	if path == "" || !fileExists(path) {
+		return
+	}
+	if b, ok := ctx.Value(binlogsKey{}).(*binlogs); ok {
+		b.report(pulumirpc.LogSeverity_INFO, "MSBuild binary log written to "+path)
+	}
+}
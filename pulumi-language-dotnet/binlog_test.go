@@ -0,0 +1,127 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:paralleltest // sets environment variables
+func TestParseBinlogOption(t *testing.T) {
+	t.Setenv(binlogEnvVar, "")
+	root := t.TempDir()
+	cacheDir, err := os.UserCacheDir()
+	require.NoError(t, err)
+	defaultDir := filepath.Join(cacheDir, "pulumi-dotnet", "binlogs")
+
+	dir, err := parseBinlogOption(root, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, "", dir)
+	dir, err = parseBinlogOption(root, map[string]interface{}{"binlog": true})
+	require.NoError(t, err)
+	assert.Equal(t, defaultDir, dir)
+	dir, err = parseBinlogOption(root, map[string]interface{}{"binlog": "logs"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "logs"), dir)
+	_, err = parseBinlogOption(root, map[string]interface{}{"binlog": float64(1)})
+	assert.ErrorContains(t, err, "binlog option must be a bool or a directory")
+
+	// The environment variable turns them on when the option doesn't say.
+	t.Setenv(binlogEnvVar, "1")
+	dir, err = parseBinlogOption(root, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultDir, dir)
+	t.Setenv(binlogEnvVar, filepath.Join(root, "support"))
+	dir, err = parseBinlogOption(root, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "support"), dir)
+	dir, err = parseBinlogOption(root, map[string]interface{}{"binlog": false})
+	require.NoError(t, err)
+	assert.Equal(t, "", dir)
+}
+
+func TestBinlogs(t *testing.T) {
+	t.Parallel()
+
+	base := filepath.Join(t.TempDir(), "binlogs")
+	var reported bytes.Buffer
+	ctx := dotnetOptions{binlog: base}.withBinlogs(t.Context(), "GetRequiredPackages", &reported)
+
+	// Commands that don't run MSBuild, or don't take its switches, get none.
+	assert.Equal(t, "", binlogPath(ctx, []string{"--version"}))
+	assert.Equal(t, "", binlogPath(ctx, []string{"run", "--project", "Infra.csproj"}))
+	assert.Equal(t, "", binlogPath(ctx, []string{"build", "Program.cs"}))
+	assert.Equal(t, "", binlogPath(dotnetOptions{}.withBinlogs(t.Context(), "Run", &reported), []string{"build"}))
+	assert.NoDirExists(t, base)
+
+	// The builds of an operation are numbered in a directory of its own.
+	restore := binlogPath(ctx, []string{"restore", "Infra.csproj"})
+	build := binlogPath(ctx, []string{"build", "Infra.csproj"})
+	assert.Equal(t, filepath.Dir(restore), filepath.Dir(build))
+	assert.Equal(t, base, filepath.Dir(filepath.Dir(build)))
+	assert.True(t, strings.HasPrefix(filepath.Base(filepath.Dir(build)), "GetRequiredPackages-"))
+	assert.Equal(t, "1-restore.binlog", filepath.Base(restore))
+	assert.Equal(t, "2-build.binlog", filepath.Base(build))
+	other := binlogPath(dotnetOptions{binlog: base}.withBinlogs(t.Context(), "Run", &reported), []string{"build"})
+	assert.NotEqual(t, filepath.Dir(build), filepath.Dir(other))
+
+	// Only the logs that were written are reported.
+	require.NoError(t, os.WriteFile(build, nil, 0o600))
+	reportBinlog(ctx, restore)
+	reportBinlog(ctx, build)
+	assert.Equal(t, "MSBuild binary log written to "+build+"\n", reported.String())
+
+	// A directory that can't be used is reported once, rather than leaving the user without logs unawares.
+	reported.Reset()
+	notADir := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(notADir, nil, 0o600))
+	ctx = dotnetOptions{binlog: notADir}.withBinlogs(t.Context(), "Run", &reported)
+	assert.Equal(t, "", binlogPath(ctx, []string{"build"}))
+	assert.Equal(t, "", binlogPath(ctx, []string{"build"}))
+	assert.Equal(t, 1, strings.Count(reported.String(), "not writing MSBuild binary logs: "), reported.String())
+}
+
+func TestDotnetCommandBinlog(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script to stand in for dotnet")
+	}
+
+	// Stands in for `dotnet pack`, writing the binary log it's asked for.
+	fakeDotnet := filepath.Join(t.TempDir(), "dotnet")
+	require.NoError(t, os.WriteFile(fakeDotnet, []byte(`#!/bin/sh
+for arg; do
+  case "$arg" in -bl:*) echo "${arg#-bl:}"; touch "${arg#-bl:}";; esac
+done
+`), 0o700))
+
+	var reported bytes.Buffer
+	ctx := dotnetOptions{binlog: t.TempDir()}.withBinlogs(t.Context(), "Pack", &reported)
+	cmd, release := dotnetCommand(ctx, fakeDotnet, "pack", "Infra.csproj")
+	out, err := cmd.Output()
+	release()
+	require.NoError(t, err)
+	path := strings.TrimSpace(string(out))
+	assert.FileExists(t, path)
+	assert.Equal(t, "MSBuild binary log written to "+path+"\n", reported.String())
+}
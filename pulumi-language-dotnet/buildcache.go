@@ -0,0 +1,336 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// buildCacheFile is the name of the file, inside the project's `obj` directory, that records the last build.
+const buildCacheFile = "pulumi-build-cache.json"
+
+// buildInputExtensions are the files below a project directory that can change the result of a build.
+var buildInputExtensions = map[string]bool{
+	".cs":           true,
+	".fs":           true,
+	".fsi":          true,
+	".vb":           true,
+	".csproj":       true,
+	".fsproj":       true,
+	".vbproj":       true,
+	".props":        true,
+	".targets":      true,
+	".resx":         true,
+	".editorconfig": true,
+	".globalconfig": true,
+}
+
+// buildInputFiles are files that can change the result of a build wherever they are in the directory hierarchy.
+var buildInputFiles = []string{
+	"Directory.Build.props",
+	"Directory.Build.targets",
+	"Directory.Packages.props",
+	"global.json",
+	"NuGet.Config",
+	"nuget.config",
+	"NuGet.config",
+	"packages.lock.json",
+}
+
+var (
+	// Matches the attributes of an `<Import />` element.
+	importRegexp = regexp.MustCompile(`<Import\s([^>]*)>`)
+	// Match the Project attribute, and the presence of an Sdk attribute, of an `<Import />` element.
+	importProjectRegexp = regexp.MustCompile(`\bProject\s*=\s*"([^"]*)"`)
+	importSdkRegexp     = regexp.MustCompile(`\bSdk\s*=\s*"`)
+	// Matches the import of the next `Directory.Build.props` or similar file up the directory hierarchy, which are
+	// build inputs already.
+	importFileAboveRegexp = regexp.MustCompile(`^\$\(\[MSBuild\]::GetPathOfFileAbove\(\s*'?([^',)]+)'?`)
+)
+
+// buildCache remembers the inputs of the last successful `dotnet build` of a project, so that later builds, even by
+// other language host processes, can be skipped when nothing has changed.
+type buildCache struct {
+	path        string
+	fingerprint string
+	sdkVersion  string
+}
+
+type buildCacheEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	SDKVersion  string `json:"sdkVersion"`
+	// Outputs are the assemblies the build produced, they must all still exist for the build to be up to date.
+	Outputs []string `json:"outputs"`
+}
+
+// newBuildCache fingerprints the current inputs to building project with the given arguments.
+func newBuildCache(ctx context.Context, dotnetExec, project string, buildArgs []string) (*buildCache, error) {
+	projectFile, err := findProjectFile(project)
+	if err != nil {
+		return nil, err
+	}
+	if isFileBasedProgram(projectFile) {
+		// `dotnet run` keeps track of those itself, and there's no obj directory to keep the cache in.
+		return nil, errors.Errorf("%s is a file-based program, dotnet caches its builds itself", projectFile)
+	}
+
+	// The SDK version depends on any `global.json` in scope, so ask from the project's directory.
+	cmd, release := dotnetCommand(ctx, dotnetExec, "--version")
+	cmd.Dir = filepath.Dir(projectFile)
+	out, err := cmd.Output()
+	release()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not determine the .NET SDK version")
+	}
+	sdkVersion := strings.TrimSpace(string(out))
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "sdk %s\n", sdkVersion)
+	fmt.Fprintf(hash, "args %q\n", buildArgs)
+	if err := fingerprintProject(hash, projectFile, map[string]bool{}); err != nil {
+		return nil, err
+	}
+
+	return &buildCache{
+		path:        filepath.Join(filepath.Dir(projectFile), "obj", buildCacheFile),
+		fingerprint: hex.EncodeToString(hash.Sum(nil)),
+		sdkVersion:  sdkVersion,
+	}, nil
+}
+
+// fingerprintProject writes the path, size and modification time of every build input of projectFile, including the
+// files it imports, and of the projects it references, to w.
+func fingerprintProject(w io.Writer, projectFile string, visited map[string]bool) error {
+	if visited[projectFile] {
+		return nil
+	}
+	visited[projectFile] = true
+
+	projectDir := filepath.Dir(projectFile)
+	var inputs []string
+	err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != projectDir && (name == "bin" || name == "obj" || name == "node_modules" ||
+				strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if buildInputExtensions[strings.ToLower(filepath.Ext(path))] {
+			inputs = append(inputs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for dir := projectDir; ; dir = filepath.Dir(dir) {
+		for _, name := range buildInputFiles {
+			inputs = append(inputs, filepath.Join(dir, name))
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	sort.Strings(inputs)
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s %d %d\n", input, info.Size(), info.ModTime().UnixNano())
+	}
+
+	// Props and targets can be imported from anywhere, such as a shared directory next to the project's.
+	for _, input := range append([]string{projectFile}, inputs...) {
+		if input == projectFile || isMSBuildFile(input) {
+			if err := fingerprintImports(w, input, projectDir, visited); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Changes to referenced projects change our build too.
+	refs, err := projectReferences(projectFile)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if _, err := os.Stat(ref); err != nil {
+			// Let the build report the missing project.
+			fmt.Fprintf(w, "missing %s\n", ref)
+			continue
+		}
+		if err := fingerprintProject(w, ref, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fingerprintImports writes the path, size and modification time of every file that file imports, and that those
+// import in turn, to w.  It fails for imports whose path depends on anything but the importing file's or the
+// project's directory, since the build could read a file that isn't part of the fingerprint.
+func fingerprintImports(w io.Writer, file, projectDir string, visited map[string]bool) error {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, m := range importRegexp.FindAllStringSubmatch(string(data), -1) {
+		if importSdkRegexp.MatchString(m[1]) {
+			// Imported from the SDK, which is part of the fingerprint already.
+			continue
+		}
+		project := importProjectRegexp.FindStringSubmatch(m[1])
+		if project == nil {
+			continue
+		}
+		if above := importFileAboveRegexp.FindStringSubmatch(project[1]); above != nil &&
+			slices.Contains(buildInputFiles, strings.TrimSpace(above[1])) {
+			continue
+		}
+
+		path := strings.NewReplacer(
+			"$(MSBuildThisFileDirectory)", filepath.Dir(file)+string(filepath.Separator),
+			"$(MSBuildProjectDirectory)", projectDir,
+		).Replace(project[1])
+		if strings.ContainsAny(path, "$@*?") {
+			return errors.Errorf("%s imports %q, which the build cache can't resolve", file, project[1])
+		}
+		path = filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		path = filepath.Clean(path)
+
+		if visited[path] {
+			continue
+		}
+		visited[path] = true
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			// Likely imported on a condition, creating it changes the build though.
+			fmt.Fprintf(w, "missing %s\n", path)
+			continue
+		} else if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		if err := fingerprintImports(w, path, projectDir, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isMSBuildFile returns true for files MSBuild evaluates, which can import others.
+func isMSBuildFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".props", ".targets", ".csproj", ".fsproj", ".vbproj":
+		return true
+	}
+	return false
+}
+
+// upToDate returns true if the last recorded build had the same inputs and its outputs are still there.
+func (c *buildCache) upToDate() bool {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.V(5).Infof("could not read build cache %s: %v", c.path, err)
+		}
+		return false
+	}
+	var entry buildCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logging.V(5).Infof("could not parse build cache %s: %v", c.path, err)
+		return false
+	}
+
+	if entry.SDKVersion != c.sdkVersion {
+		logging.V(5).Infof("build cache %s is for .NET SDK %s, not %s", c.path, entry.SDKVersion, c.sdkVersion)
+		return false
+	}
+	if entry.Fingerprint != c.fingerprint || len(entry.Outputs) == 0 {
+		return false
+	}
+	for _, output := range entry.Outputs {
+		if _, err := os.Stat(output); err != nil {
+			logging.V(5).Infof("build output %s is missing: %v", output, err)
+			return false
+		}
+	}
+	return true
+}
+
+// record saves the fingerprint of a successful build along with the outputs reported by `dotnet build`.
+func (c *buildCache) record(buildOutput string) error {
+	outputs := parseBuildOutputs(buildOutput)
+	if len(outputs) == 0 {
+		// Without knowing what was built we can't check it's still there later.
+		return errors.New("could not find the build outputs in the output of 'dotnet build'")
+	}
+
+	data, err := json.Marshal(buildCacheEntry{
+		Fingerprint: c.fingerprint,
+		SDKVersion:  c.sdkVersion,
+		Outputs:     outputs,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o600)
+}
+
+// Matches the `  Project -> /path/to/Project.dll` lines MSBuild prints for each output.
+var buildOutputRegexp = regexp.MustCompile(`(?m)^\s*\S+ -> (.+?)\s*$`)
+
+func parseBuildOutputs(buildOutput string) []string {
+	var outputs []string
+	for _, m := range buildOutputRegexp.FindAllStringSubmatch(buildOutput, -1) {
+		if filepath.IsAbs(m[1]) {
+			outputs = append(outputs, m[1])
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,174 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBuildOutputs(t *testing.T) {
+	t.Parallel()
+
+	output := "  Determining projects to restore...\n" +
+		"  All projects are up-to-date for restore.\n" +
+		"  Components -> /src/Components/bin/Debug/net8.0/Components.dll\r\n" +
+		"  Infra -> /src/Infra/bin/Debug/net8.0/Infra.dll\n" +
+		"\n" +
+		"Build succeeded.\n"
+
+	assert.Equal(t, []string{
+		"/src/Components/bin/Debug/net8.0/Components.dll",
+		"/src/Infra/bin/Debug/net8.0/Infra.dll",
+	}, parseBuildOutputs(output))
+}
+
+func TestBuildCache(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	programDir := filepath.Join(root, "program")
+	componentsDir := filepath.Join(root, "components")
+	require.NoError(t, os.MkdirAll(programDir, 0o700))
+	require.NoError(t, os.MkdirAll(componentsDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(programDir, "Infra.csproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <ProjectReference Include="..\components\Components.csproj" />
+  </ItemGroup>
+</Project>`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(programDir, "Program.cs"), []byte("// v1"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(componentsDir, "Components.csproj"), []byte("<Project />"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(componentsDir, "Component.cs"), []byte("// v1"), 0o600))
+
+	output := filepath.Join(programDir, "bin", "Infra.dll")
+	require.NoError(t, os.MkdirAll(filepath.Dir(output), 0o700))
+	require.NoError(t, os.WriteFile(output, nil, 0o600))
+
+	newCache := func(args ...string) *buildCache {
+		cache, err := newBuildCache(t.Context(), "dotnet", programDir, append(dotnetOptions{}.buildArgs(programDir), args...))
+		require.NoError(t, err)
+		return cache
+	}
+	touch := func(path string) {
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(path, later, later))
+	}
+
+	cache := newCache()
+	assert.False(t, cache.upToDate(), "nothing has been built yet")
+
+	assert.Error(t, cache.record("Build succeeded."))
+	require.NoError(t, cache.record("  Infra -> "+output+"\n"))
+	assert.True(t, newCache().upToDate())
+	assert.FileExists(t, filepath.Join(programDir, "obj", buildCacheFile))
+
+	// Different build arguments are a different build.
+	assert.False(t, newCache("--configuration", "Release").upToDate())
+
+	// Build outputs don't affect the fingerprint.
+	require.NoError(t, os.WriteFile(filepath.Join(programDir, "obj", "Generated.cs"), nil, 0o600))
+	assert.True(t, newCache().upToDate())
+
+	// Changes to referenced projects do.
+	touch(filepath.Join(componentsDir, "Component.cs"))
+	assert.False(t, newCache().upToDate())
+	require.NoError(t, newCache().record("  Infra -> "+output+"\n"))
+	assert.True(t, newCache().upToDate())
+
+	// As do changes to sources of the project itself.
+	touch(filepath.Join(programDir, "Program.cs"))
+	assert.False(t, newCache().upToDate())
+	require.NoError(t, newCache().record("  Infra -> "+output+"\n"))
+	assert.True(t, newCache().upToDate())
+
+	// And a `dotnet clean`.
+	require.NoError(t, os.Remove(output))
+	assert.False(t, newCache().upToDate())
+}
+
+func TestFingerprintProjectImports(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	programDir := filepath.Join(root, "program")
+	sharedDir := filepath.Join(root, "shared")
+	require.NoError(t, os.MkdirAll(programDir, 0o700))
+	require.NoError(t, os.MkdirAll(sharedDir, 0o700))
+	projectFile := filepath.Join(programDir, "Infra.csproj")
+	require.NoError(t, os.WriteFile(projectFile, []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <Import Project="Sdk.props" Sdk="Microsoft.NET.Sdk" />
+  <Import Project="..\shared\common.props" />
+</Project>`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Directory.Build.props"), []byte(`<Project>
+  <Import Project="$([MSBuild]::GetPathOfFileAbove('Directory.Build.props', '$(MSBuildThisFileDirectory)../'))" />
+</Project>`), 0o600))
+	common := filepath.Join(sharedDir, "common.props")
+	require.NoError(t, os.WriteFile(common, []byte(`<Project>
+  <Import Project="$(MSBuildThisFileDirectory)versions.props"
+          Condition="Exists('$(MSBuildThisFileDirectory)versions.props')" />
+</Project>`), 0o600))
+
+	fingerprint := func() ([]byte, error) {
+		hash := sha256.New()
+		err := fingerprintProject(hash, projectFile, map[string]bool{})
+		return hash.Sum(nil), err
+	}
+	before, err := fingerprint()
+	require.NoError(t, err)
+
+	// Files imported from outside the project's directory are inputs, even those that are only imported by them.
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(common, later, later))
+	after, err := fingerprint()
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+
+	require.NoError(t, os.WriteFile(filepath.Join(sharedDir, "versions.props"), []byte("<Project />"), 0o600))
+	before = after
+	after, err = fingerprint()
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+
+	// Imports that depend on properties can't be fingerprinted.
+	require.NoError(t, os.WriteFile(common, []byte(`<Project>
+  <Import Project="$(SharedBuildDir)\common.targets" />
+</Project>`), 0o600))
+	_, err = fingerprint()
+	assert.ErrorContains(t, err, `common.props imports "$(SharedBuildDir)\\common.targets"`)
+}
+
+func TestBuildCacheSDKVersion(t *testing.T) {
+	t.Parallel()
+
+	programDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(programDir, "Infra.csproj"), []byte("<Project />"), 0o600))
+	output := filepath.Join(programDir, "Infra.dll")
+	require.NoError(t, os.WriteFile(output, nil, 0o600))
+
+	cache, err := newBuildCache(t.Context(), "dotnet", programDir, dotnetOptions{}.buildArgs(programDir))
+	require.NoError(t, err)
+	cache.sdkVersion = "6.0.100"
+	require.NoError(t, cache.record("  Infra -> "+output+"\n"))
+
+	cache, err = newBuildCache(t.Context(), "dotnet", programDir, dotnetOptions{}.buildArgs(programDir))
+	require.NoError(t, err)
+	assert.False(t, cache.upToDate())
+}
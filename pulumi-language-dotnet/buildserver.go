@@ -0,0 +1,36 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// nodeReuseArgs returns the MSBuild switches for commands that accept them, `dotnet run` doesn't.
+func (opts dotnetOptions) nodeReuseArgs() []string {
+	switch {
+	case opts.buildServer == nil:
+		return nil
+	case *opts.buildServer:
+		return []string{"-nodeReuse:true"}
+	default:
+		return []string{"-nodeReuse:false"}
+	}
+}
+
+// buildServerEnv returns the environment variables that make commands that don't accept MSBuild switches, such as
+// `dotnet run`, follow the buildServer option.
+func (opts dotnetOptions) buildServerEnv() []string {
+	if opts.buildServer != nil && !*opts.buildServer {
+		return []string{"MSBUILDDISABLENODEREUSE=1"}
+	}
+	return nil
+}
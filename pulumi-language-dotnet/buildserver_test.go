@@ -0,0 +1,48 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildServerOption(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseOptions("", map[string]interface{}{"use-executor": "dotnet"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build", "-nologo", "Infra.csproj"}, opts.buildArgs("Infra.csproj"))
+	assert.Empty(t, opts.buildServerEnv())
+
+	opts, err = parseOptions("", map[string]interface{}{"use-executor": "dotnet", "buildServer": true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build", "-nologo", "Infra.csproj", "-nodeReuse:true"}, opts.buildArgs("Infra.csproj"))
+	assert.Empty(t, opts.msbuildArgs())
+	assert.Empty(t, opts.buildServerEnv())
+
+	opts, err = parseOptions("", map[string]interface{}{"use-executor": "dotnet", "buildServer": false})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"build", "-nologo", "Infra.csproj", "--property:UseSharedCompilation=false", "-nodeReuse:false",
+	}, opts.buildArgs("Infra.csproj"))
+	assert.Equal(t, []string{"--property:UseSharedCompilation=false"}, opts.msbuildArgs())
+	assert.Equal(t, []string{"MSBUILDDISABLENODEREUSE=1"}, opts.buildServerEnv())
+
+	_, err = parseOptions("", map[string]interface{}{"use-executor": "dotnet", "buildServer": "yes"})
+	assert.ErrorContains(t, err, "buildServer option must be a bool")
+}
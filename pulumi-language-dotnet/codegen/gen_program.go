@@ -471,6 +471,22 @@ func GenerateProject(
 	if err != nil {
 		return err
 	}
+	if propsFile := CentralPackageVersionsFile(directory); propsFile != "" {
+		// Versions in the project file would fail the restore with NU1008.
+		info, err := os.Stat(propsFile)
+		if err != nil {
+			return fmt.Errorf("update %s: %w", propsFile, err)
+		}
+		props, err := os.ReadFile(propsFile)
+		if err != nil {
+			return fmt.Errorf("update %s: %w", propsFile, err)
+		}
+		var updated []byte
+		csproj, updated = UseCentralPackageVersions(csproj, props)
+		if err := os.WriteFile(propsFile, updated, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("update %s: %w", propsFile, err)
+		}
+	}
 	files[project.Name.String()+".csproj"] = csproj
 
 	// Add the language specific .gitignore
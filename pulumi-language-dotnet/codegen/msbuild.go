@@ -0,0 +1,176 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotnet
+
+import (
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	manageVersionsCentrallyRegexp = regexp.MustCompile(
+		`(?i)<ManagePackageVersionsCentrally(?:\s[^>]*)?>\s*true\s*</ManagePackageVersionsCentrally>`)
+	packageReferenceRegexp   = regexp.MustCompile(`<PackageReference\b[^>]*>`)
+	includeAttributeRegexp   = regexp.MustCompile(`\sInclude\s*=\s*"([^"]*)"`)
+	versionAttributeRegexp   = regexp.MustCompile(`\s+Version\s*=\s*"([^"]*)"`)
+	projectOpenRegexp        = regexp.MustCompile(`<Project\b[^>]*>$`)
+	firstIndentRegexp        = regexp.MustCompile(`\n([ \t]+)<`)
+	selfClosingProjectRegexp = regexp.MustCompile(`<Project\b[^>]*?/>`)
+)
+
+// CentralPackageVersionsFile returns the `Directory.Packages.props` that manages the versions of the packages
+// referenced by projects in directory, or "" if their versions aren't managed centrally.
+func CentralPackageVersionsFile(directory string) string {
+	propsFile, buildPropsFile := "", ""
+	for d := directory; ; d = filepath.Dir(d) {
+		// Like MSBuild, only the closest of each file is imported.
+		if path := filepath.Join(d, "Directory.Packages.props"); propsFile == "" && fileExists(path) {
+			propsFile = path
+		}
+		if path := filepath.Join(d, "Directory.Build.props"); buildPropsFile == "" && fileExists(path) {
+			buildPropsFile = path
+		}
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+	if propsFile == "" {
+		return ""
+	}
+	for _, path := range []string{propsFile, buildPropsFile} {
+		if data, err := os.ReadFile(path); err == nil && manageVersionsCentrallyRegexp.Match(data) {
+			return propsFile
+		}
+	}
+	return ""
+}
+
+// UseCentralPackageVersions moves the versions of the package references in project to `PackageVersion` entries in
+// props, the contents of a `Directory.Packages.props`, and returns the project without them along with the updated
+// props.  Packages that props already has a version for keep it, those pins are the user's to manage.
+func UseCentralPackageVersions(project, props []byte) ([]byte, []byte) {
+	contents := string(props)
+	result := packageReferenceRegexp.ReplaceAllStringFunc(string(project), func(reference string) string {
+		include := includeAttributeRegexp.FindStringSubmatch(reference)
+		version := versionAttributeRegexp.FindStringSubmatch(reference)
+		if include == nil || version == nil {
+			return reference
+		}
+		contents = setPackageVersion(contents, html.UnescapeString(include[1]), html.UnescapeString(version[1]), false)
+		return versionAttributeRegexp.ReplaceAllString(reference, "")
+	})
+	return []byte(result), []byte(contents)
+}
+
+// SetCentralPackageVersion adds or updates the `PackageVersion` of a package in props, the contents of a
+// `Directory.Packages.props`.
+func SetCentralPackageVersion(props []byte, id, version string) []byte {
+	return []byte(setPackageVersion(string(props), id, version, true))
+}
+
+func setPackageVersion(contents, id, version string, update bool) string {
+	re := regexp.MustCompile(`(?i)<PackageVersion\s+Include\s*=\s*"` + regexp.QuoteMeta(html.EscapeString(id)) + `"[^>]*>`)
+	loc := re.FindStringIndex(contents)
+	if loc == nil {
+		return AddProjectElement(contents, "ItemGroup", "PackageVersion",
+			`<PackageVersion Include="`+html.EscapeString(id)+`" Version="`+html.EscapeString(version)+`" />`)
+	}
+	if !update {
+		return contents
+	}
+	entry := contents[loc[0]:loc[1]]
+	if m := versionAttributeRegexp.FindStringSubmatchIndex(entry); m != nil {
+		entry = entry[:m[2]] + html.EscapeString(version) + entry[m[3]:]
+	}
+	return contents[:loc[0]] + entry + contents[loc[1]:]
+}
+
+// AddProjectElement adds element to the text of an MSBuild file, after the last element with the same name if there
+// is one, and otherwise at the end of the first unconditional group of the given kind, or in a new group at the end
+// of the file.  It follows the file's indentation and line endings, and leaves everything else as it was.
+func AddProjectElement(contents, group, name, element string) string {
+	newline := "\n"
+	if strings.Contains(contents, "\r\n") {
+		newline = "\r\n"
+	}
+	indent := "  "
+	if m := firstIndentRegexp.FindStringSubmatch(contents); m != nil {
+		indent = m[1]
+	}
+
+	siblingRegexp := regexp.MustCompile(`<` + name + `\b(?:[^>]*/>|[\s\S]*?</` + name + `>)`)
+	siblings := siblingRegexp.FindAllStringIndex(contents, -1)
+	if len(siblings) > 0 {
+		last := siblings[len(siblings)-1]
+		return contents[:last[1]] + newline + lineIndent(contents, last[0]) + element + contents[last[1]:]
+	}
+
+	if open := strings.Index(contents, "<"+group+">"); open >= 0 {
+		if end := strings.Index(contents[open:], "</"+group+">"); end >= 0 {
+			end += open
+			groupIndent := lineIndent(contents, end)
+			lineStart := end - len(groupIndent)
+			if lineStart > 0 && contents[lineStart-1] == '\n' {
+				return contents[:lineStart] + groupIndent + indent + element + newline + contents[lineStart:]
+			}
+			// The group is closed on the same line as its last element.
+			return contents[:end] + newline + groupIndent + indent + element + newline + groupIndent + contents[end:]
+		}
+	}
+
+	block := indent + "<" + group + ">" + newline +
+		indent + indent + element + newline +
+		indent + "</" + group + ">" + newline
+	if m := selfClosingProjectRegexp.FindStringIndex(contents); m != nil && !strings.Contains(contents, "</Project>") {
+		// An empty project has to be opened up first.
+		open := strings.TrimRight(contents[m[0]:m[1]-2], " \t") + ">"
+		return contents[:m[0]] + open + newline + block + "</Project>" + contents[m[1]:]
+	}
+	end := strings.LastIndex(contents, "</Project>")
+	if end < 0 {
+		return contents
+	}
+	lineStart := end - len(lineIndent(contents, end))
+	prefix := ""
+	before := strings.TrimRight(contents[:lineStart], " \t\r\n")
+	switch {
+	case lineStart > 0 && contents[lineStart-1] != '\n':
+		// The project is closed on the same line as something else.
+		prefix = newline
+	case !strings.HasSuffix(contents[:lineStart], newline+newline) && !projectOpenRegexp.MatchString(before):
+		// Separate the new group from the one before it.
+		prefix = newline
+	}
+	return contents[:lineStart] + prefix + block + contents[lineStart:]
+}
+
+// lineIndent returns the whitespace between the start of the line containing offset and offset, or "" if there's
+// anything else before offset on that line.
+func lineIndent(contents string, offset int) string {
+	start := strings.LastIndex(contents[:offset], "\n") + 1
+	indent := contents[start:offset]
+	if strings.TrimLeft(indent, " \t") != "" {
+		return ""
+	}
+	return indent
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
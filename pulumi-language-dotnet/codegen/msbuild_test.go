@@ -0,0 +1,100 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotnet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCentralPackageVersionsFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	program := filepath.Join(root, "src", "program")
+	require.NoError(t, os.MkdirAll(program, 0o700))
+	assert.Equal(t, "", CentralPackageVersionsFile(program))
+
+	// Directories aren't props files.
+	require.NoError(t, os.MkdirAll(filepath.Join(program, "Directory.Packages.props"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(program, "Directory.Build.props"), []byte(`<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+  </PropertyGroup>
+</Project>`), 0o600))
+	assert.Equal(t, "", CentralPackageVersionsFile(program))
+	require.NoError(t, os.Remove(filepath.Join(program, "Directory.Build.props")))
+
+	propsFile := filepath.Join(root, "Directory.Packages.props")
+	require.NoError(t, os.WriteFile(propsFile, []byte("<Project />"), 0o600))
+	assert.Equal(t, "", CentralPackageVersionsFile(program))
+
+	// Central package management can be turned on by Directory.Build.props too.
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "Directory.Build.props"), []byte(`<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+  </PropertyGroup>
+</Project>`), 0o600))
+	assert.Equal(t, propsFile, CentralPackageVersionsFile(program))
+}
+
+func TestUseCentralPackageVersions(t *testing.T) {
+	t.Parallel()
+
+	props := []byte(`<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageVersion Include="Pulumi" Version="3.90.0" />
+  </ItemGroup>
+</Project>
+`)
+
+	project, props := UseCentralPackageVersions([]byte(`<Project Sdk="Microsoft.NET.Sdk">
+	<ItemGroup>
+		<PackageReference Include="Pulumi" Version="3.*" />
+		<PackageReference Include="Pulumi.Random" Version="4.16.0" ExcludeAssets="contentFiles" />
+		<ProjectReference Include="../sdks/tls/Pulumi.Tls.csproj" />
+	</ItemGroup>
+</Project>`), props)
+	assert.Equal(t, `<Project Sdk="Microsoft.NET.Sdk">
+	<ItemGroup>
+		<PackageReference Include="Pulumi" />
+		<PackageReference Include="Pulumi.Random" ExcludeAssets="contentFiles" />
+		<ProjectReference Include="../sdks/tls/Pulumi.Tls.csproj" />
+	</ItemGroup>
+</Project>`, string(project))
+
+	// The existing pin is kept, and the new package's version added next to it.
+	assert.Equal(t, `<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageVersion Include="Pulumi" Version="3.90.0" />
+    <PackageVersion Include="Pulumi.Random" Version="4.16.0" />
+  </ItemGroup>
+</Project>
+`, string(props))
+
+	props = SetCentralPackageVersion(props, "Pulumi.Random", "4.17.0")
+	assert.Contains(t, string(props), `<PackageVersion Include="Pulumi.Random" Version="4.17.0" />`)
+	assert.NotContains(t, string(props), "4.16.0")
+}
@@ -0,0 +1,152 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// The configuration of a program used to go only in the PULUMI_CONFIG and PULUMI_CONFIG_SECRET_KEYS environment
+// variables, which large stacks can outgrow and which anything that dumps the environment leaks secrets from.  SDKs
+// that support it read it from a file only the user can read instead, named by PULUMI_CONFIG_FILE:
+//
+//	{"config": {"proj:key": "value"}, "secretKeys": ["proj:key"]}
+//
+// Older SDKs still get the environment variables.
+
+// configFileSdkVersion is the first version of the Pulumi SDK that reads PULUMI_CONFIG_FILE.  Until that ships it's
+// the version changie will release the SDK's changelog entry for it in, TestSdkFeatureVersions fails if they drift.
+var configFileSdkVersion = semver.MustParse("3.113.0")
+
+// writeConfigFile writes the JSON-serialized configuration and secret keys of a program to a new file that only the
+// user can read, and returns its path.  The caller removes it once the program has exited.
+func writeConfigFile(config, configSecretKeys string) (string, error) {
+	if config == "" {
+		config = "{}"
+	}
+	data, err := json.Marshal(struct {
+		Config     json.RawMessage `json:"config"`
+		SecretKeys json.RawMessage `json:"secretKeys"`
+	}{json.RawMessage(config), json.RawMessage(configSecretKeys)})
+	if err != nil {
+		return "", err
+	}
+
+	// CreateTemp makes the file with 0600 permissions.
+	f, err := os.CreateTemp("", "pulumi-dotnet-config-*.json")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// projectReadsConfigFile returns whether a program built from project uses an SDK that reads PULUMI_CONFIG_FILE.
+// This is decided before `dotnet run` restores the project, so only a restore that is still up to date can say so: an
+// assets file older than the project file, or any other input to the restore, counts as not knowing.
+func projectReadsConfigFile(project string) bool {
+	var versions []string
+	if isFileBasedProgram(project) {
+		packageDirs, err := resolvePackageFolders(project)
+		if err != nil {
+			logging.V(5).Infof("finding the Pulumi SDK of %s: %v", project, err)
+		}
+		list, err := fileProgramPackages(project, packageDirs)
+		if err != nil {
+			logging.V(5).Infof("finding the Pulumi SDK of %s: %v", project, err)
+			return false
+		}
+		for _, pkg := range list.Projects[0].Frameworks[0].Packages {
+			if !strings.EqualFold(pkg.ID, "Pulumi") {
+				continue
+			}
+			if strings.ContainsAny(pkg.RequestedVersion, "[]()") {
+				// The highest restored version needn't be in the range, so which one runs isn't known.
+				return false
+			}
+			versions = append(versions, pkg.ResolvedVersion)
+		}
+	} else {
+		assets, _, err := readProjectAssets(project)
+		if err != nil {
+			logging.V(5).Infof("finding the Pulumi SDK of %s: %v", project, err)
+			return false
+		}
+		for key, library := range assets.Libraries {
+			if id, version, ok := strings.Cut(key, "/"); ok && library.Type == "package" && strings.EqualFold(id, "Pulumi") {
+				versions = append(versions, version)
+			}
+		}
+	}
+	return sdkVersionsReadConfigFile(versions)
+}
+
+// binaryReadsConfigFile returns whether the program binaryPath, a dll or an executable, uses an SDK that reads
+// PULUMI_CONFIG_FILE, going by the `.deps.json` file the build wrote next to it.
+func binaryReadsConfigFile(binaryPath string) bool {
+	depsPath := binaryPath
+	if ext := filepath.Ext(binaryPath); ext == ".dll" || ext == ".exe" {
+		depsPath = strings.TrimSuffix(binaryPath, ext)
+	}
+	data, err := os.ReadFile(depsPath + ".deps.json")
+	if err != nil {
+		logging.V(5).Infof("finding the Pulumi SDK of %s: %v", binaryPath, err)
+		return false
+	}
+	var deps struct {
+		Libraries map[string]struct {
+			Type string `json:"type"`
+		} `json:"libraries"`
+	}
+	if err := json.Unmarshal(data, &deps); err != nil {
+		logging.V(5).Infof("finding the Pulumi SDK of %s: %v", binaryPath, err)
+		return false
+	}
+	var versions []string
+	for key, library := range deps.Libraries {
+		if id, version, ok := strings.Cut(key, "/"); ok && library.Type == "package" && strings.EqualFold(id, "Pulumi") {
+			versions = append(versions, version)
+		}
+	}
+	return sdkVersionsReadConfigFile(versions)
+}
+
+// sdkVersionsReadConfigFile returns whether the Pulumi SDK versions a program uses all read PULUMI_CONFIG_FILE.  An
+// SDK of unknown version, such as one referenced as a project, might not.
+func sdkVersionsReadConfigFile(versions []string) bool {
+	if len(versions) == 0 {
+		return false
+	}
+	for _, version := range versions {
+		v, err := semver.ParseTolerant(version)
+		if err != nil || v.LT(configFileSdkVersion) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteConfigFile(t *testing.T) {
+	t.Parallel()
+
+	path, err := writeConfigFile(`{"proj:password":"hunter2"}`, `["proj:password"]`)
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Remove(path) })
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"config":{"proj:password":"hunter2"},"secretKeys":["proj:password"]}`, string(data))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	// A run without any configuration still gets an object.
+	path, err = writeConfigFile("", "[]")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Remove(path) })
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"config":{},"secretKeys":[]}`, string(data))
+}
+
+func TestProjectReadsConfigFile(t *testing.T) {
+	t.Parallel()
+
+	projectDir, _ := writeTestAssets(t, nil)
+	assert.False(t, projectReadsConfigFile(projectDir))
+
+	projectDir, _ = writeTestAssets(t, func(assets map[string]any) {
+		assets["libraries"].(map[string]any)["Pulumi/3.113.0"] = assets["libraries"].(map[string]any)["Pulumi/3.60.0"]
+		delete(assets["libraries"].(map[string]any), "Pulumi/3.60.0")
+	})
+	assert.True(t, projectReadsConfigFile(projectDir))
+
+	// A project changed since its restore, say to an older SDK, will be restored again by `dotnet run`.
+	later := time.Now().Add(2 * time.Minute)
+	projectFile := filepath.Join(projectDir, "Infra.csproj")
+	require.NoError(t, os.Chtimes(projectFile, later, later))
+	assert.False(t, projectReadsConfigFile(projectDir))
+
+	// Without a restore, there's no telling which SDK the program will get.
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "obj", "project.assets.json")))
+	assert.False(t, projectReadsConfigFile(projectDir))
+
+	// Nor for a file-based program asking for a range, which the highest restored version might be outside of.
+	root := t.TempDir()
+	packages := filepath.Join(root, "packages")
+	require.NoError(t, os.MkdirAll(filepath.Join(packages, "pulumi", "3.113.0"), 0o700))
+	program := filepath.Join(root, "Program.cs")
+	writeProgram := func(version string) {
+		require.NoError(t, os.WriteFile(program, []byte("#:package Pulumi@"+version+"\n"+
+			"#:property RestorePackagesPath=$(MSBuildProjectDirectory)/packages\n"), 0o600))
+	}
+	writeProgram("3.*")
+	assert.True(t, projectReadsConfigFile(program))
+	writeProgram("[3.0.0, 3.113.0)")
+	assert.False(t, projectReadsConfigFile(program))
+}
+
+func TestBinaryReadsConfigFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeDeps := func(name, libraries string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".deps.json"), []byte(`{"libraries": {`+libraries+`}}`),
+			0o600))
+	}
+	writeDeps("New", `"Pulumi/3.113.0": {"type": "package"}, "Google.Protobuf/3.10.0": {"type": "package"}`)
+	writeDeps("Old", `"Pulumi/3.112.1": {"type": "package"}`)
+	writeDeps("Local", `"Pulumi/1.0.0": {"type": "project"}`)
+
+	assert.True(t, binaryReadsConfigFile(filepath.Join(dir, "New.dll")))
+	assert.True(t, binaryReadsConfigFile(filepath.Join(dir, "New")))
+	assert.True(t, binaryReadsConfigFile(filepath.Join(dir, "New.exe")))
+	assert.False(t, binaryReadsConfigFile(filepath.Join(dir, "Old.dll")))
+	assert.False(t, binaryReadsConfigFile(filepath.Join(dir, "Local.dll")))
+	assert.False(t, binaryReadsConfigFile(filepath.Join(dir, "Missing.dll")))
+}
+
+// TestSdkFeatureVersions checks that the SDK versions the language host looks for are the ones whose changelog has the
+// feature: a release in .changes, or while it's unreleased, the minor release changie cuts next.
+func TestSdkFeatureVersions(t *testing.T) {
+	t.Parallel()
+
+	changes := filepath.Join("..", ".changes")
+	entries, err := os.ReadDir(changes)
+	require.NoError(t, err)
+	var latest semver.Version
+	for _, entry := range entries {
+		name := entry.Name()
+		if v, err := semver.Parse(strings.TrimSuffix(strings.TrimPrefix(name, "v"), ".md")); err == nil && v.GT(latest) {
+			latest = v
+		}
+	}
+	unreleased, err := filepath.Glob(filepath.Join(changes, "unreleased", "*.yaml"))
+	require.NoError(t, err)
+
+	for feature, version := range map[string]semver.Version{
+		"PULUMI_CONFIG_FILE":          configFileSdkVersion,
+		"PULUMI_DOTNET_PROVIDER_PORT": watchSdkVersion,
+	} {
+		if release, err := os.ReadFile(filepath.Join(changes, "v"+version.String()+".md")); err == nil {
+			assert.Contains(t, string(release), feature, "the changelog of %s", version)
+			continue
+		}
+		assert.Equal(t, semver.Version{Major: latest.Major, Minor: latest.Minor + 1}, version,
+			"%s isn't released, so it has to be the next release", version)
+		found := false
+		for _, path := range unreleased {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			found = found || (strings.Contains(string(data), "component: sdk") && strings.Contains(string(data), feature))
+		}
+		assert.True(t, found, "no unreleased sdk changelog entry mentions %s", feature)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// defaultDebuggerTimeout is how long the engine has to start a debugger unless the `debugger` option says otherwise.
+const defaultDebuggerTimeout = time.Minute
+
+// debuggerOptions configures how a debugger is attached to the program, from the `debugger` runtime option.
+type debuggerOptions struct {
+	// The type of the debug configuration, coreclr unless set.
+	typ string
+	// Whether to step into only user code.  When unset the debugger's default applies.
+	justMyCode *bool
+	// Directories to look for symbols in, besides the ones next to the assemblies.
+	symbolSearchPaths []string
+	// How long to wait for the debugger to attach, or zero for the default.
+	timeout time.Duration
+	// Whether the program waits for the user to attach a debugger themselves, even if the engine isn't attaching one.
+	wait bool
+}
+
+// parseDebuggerOptions parses the `debugger` runtime option.  Relative symbol search paths are relative to root.
+func parseDebuggerOptions(root string, value interface{}) (debuggerOptions, error) {
+	var opts debuggerOptions
+	options, ok := value.(map[string]interface{})
+	if !ok {
+		return opts, errors.New("debugger option must be a map")
+	}
+
+	for key, value := range options {
+		switch key {
+		case "type":
+			typ, ok := value.(string)
+			if !ok {
+				return opts, errors.New("debugger.type option must be a string")
+			}
+			opts.typ = typ
+		case "justMyCode":
+			justMyCode, ok := value.(bool)
+			if !ok {
+				return opts, errors.New("debugger.justMyCode option must be a bool")
+			}
+			opts.justMyCode = &justMyCode
+		case "symbolSearchPaths":
+			paths, ok := value.([]interface{})
+			if !ok {
+				return opts, errors.New("debugger.symbolSearchPaths option must be a list of strings")
+			}
+			for _, path := range paths {
+				path, ok := path.(string)
+				if !ok {
+					return opts, errors.New("debugger.symbolSearchPaths option must be a list of strings")
+				}
+				if !filepath.IsAbs(path) && root != "" {
+					path = filepath.Join(root, path)
+				}
+				opts.symbolSearchPaths = append(opts.symbolSearchPaths, path)
+			}
+		case "timeout":
+			switch timeout := value.(type) {
+			case float64:
+				opts.timeout = time.Duration(timeout * float64(time.Second))
+			case string:
+				var err error
+				if opts.timeout, err = time.ParseDuration(timeout); err != nil {
+					return opts, errors.Wrap(err, "debugger.timeout option must be a number of seconds or a duration")
+				}
+			default:
+				return opts, errors.New("debugger.timeout option must be a number of seconds or a duration")
+			}
+			if opts.timeout < 0 {
+				return opts, errors.New("debugger.timeout option must not be negative")
+			}
+		case "wait":
+			wait, ok := value.(bool)
+			if !ok {
+				return opts, errors.New("debugger.wait option must be a bool")
+			}
+			opts.wait = wait
+		default:
+			return opts, errors.Errorf("unknown debugger option %q, expected one of type, justMyCode, "+
+				"symbolSearchPaths, timeout or wait", key)
+		}
+	}
+	return opts, nil
+}
+
+// config returns the debug configuration the engine passes on to the IDE to attach to the process pid.
+func (opts debuggerOptions) config(pid int) map[string]interface{} {
+	typ := opts.typ
+	if typ == "" {
+		typ = "coreclr"
+	}
+	config := map[string]interface{}{
+		"name":      "Pulumi: Program (Dotnet)",
+		"type":      typ,
+		"request":   "attach",
+		"processId": pid,
+	}
+	if opts.justMyCode != nil {
+		config["justMyCode"] = *opts.justMyCode
+	}
+	if len(opts.symbolSearchPaths) > 0 {
+		searchPaths := make([]interface{}, len(opts.symbolSearchPaths))
+		for i, path := range opts.symbolSearchPaths {
+			searchPaths[i] = path
+		}
+		config["symbolOptions"] = map[string]interface{}{"searchPaths": searchPaths}
+	}
+	return config
+}
+
+// env returns the environment that tells the SDK to wait for a debugger before it runs the program, on top of
+// PULUMI_ATTACH_DEBUGGER, which is set when the engine attaches one.
+func (opts debuggerOptions) env() []string {
+	var env []string
+	if opts.wait {
+		env = append(env, "PULUMI_WAIT_FOR_DEBUGGER=true")
+	}
+	if opts.timeout > 0 {
+		env = append(env, "PULUMI_DEBUGGER_TIMEOUT="+strconv.FormatFloat(opts.timeout.Seconds(), 'f', -1, 64))
+	}
+	return env
+}
+
+// When debugging, we need to build the project, as the debugger does not support running using `dotnet run`.
+// This function will build the project and return the path to the built DLL.
+func buildDebuggingDLL(ctx context.Context, opts dotnetOptions, programDirectory, entryPoint string) (string, error) {
+	project, err := opts.projectPath(programDirectory, entryPoint)
+	if err != nil {
+		return "", err
+	}
+	outputDir := filepath.Join(programDirectory, "bin", "pulumi-debugging")
+	if opts.framework == "" && !isFileBasedProgram(project) {
+		// A project that targets several frameworks has no TargetPath of its own, so debug the first of them.
+		if frameworks := projectTargetFrameworks(project); len(frameworks) > 1 {
+			opts.framework = frameworks[0]
+		}
+	}
+
+	// MSBuild knows where the assembly ends up, so ask it rather than guess from the project's name.  With
+	// -getProperty the project is only evaluated unless a target is given.  Older SDKs don't have -getProperty, and
+	// for those the assembly name in the project file has to do.  File-based programs, which need a newer SDK anyway,
+	// are built where `dotnet run` would build them.
+	args := []string{"build", "-nologo", "-o", outputDir, project}
+	if isFileBasedProgram(project) {
+		args = []string{"build", "-nologo", project}
+	}
+	args = append(args, opts.msbuildArgs()...)
+	args = append(args, opts.nodeReuseArgs()...)
+	out, err := runDebuggingBuild(ctx, opts, programDirectory, append(args, "-t:Build", "-getProperty:TargetPath"))
+	if err != nil && strings.Contains(out, "MSB1001") {
+		logging.V(5).Infof("dotnet build does not support -getProperty, guessing the assembly path")
+		if out, err = runDebuggingBuild(ctx, opts, programDirectory, args); err != nil {
+			return "", errors.Wrapf(err, "failed to build project: %v, output: %v", err, out)
+		}
+		return filepath.Join(outputDir, projectAssemblyName(project)+".dll"), nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to build project: %v, output: %v", err, out)
+	}
+	path, err := targetPath(out)
+	if err != nil && opts.framework == "" {
+		// Most likely a project whose target frameworks couldn't be read without MSBuild.
+		return "", errors.Wrap(err, "if the project targets several frameworks, set the framework runtime option "+
+			"to the one to debug")
+	}
+	return path, err
+}
+
+// runDebuggingBuild runs a build for buildDebuggingDLL in dir, so that any global.json there picks the SDK like it
+// does for Run, and returns its output.
+func runDebuggingBuild(ctx context.Context, opts dotnetOptions, dir string, args []string) (string, error) {
+	logging.V(5).Infoln("Language host launching process: ", opts.dotnetExec, strings.Join(args, " "))
+	cmd, release := dotnetCommand(ctx, opts.dotnetExec, args...)
+	defer release()
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// targetPath returns the value of TargetPath printed by `dotnet build -getProperty:TargetPath`.  It is the last line,
+// after anything else the SDK has to say, such as first run messages.
+func targetPath(buildOutput string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(buildOutput), "\n")
+	path := strings.TrimSpace(lines[len(lines)-1])
+	if path == "" || !filepath.IsAbs(path) {
+		return "", errors.Errorf("could not determine the path of the built assembly from: %s", buildOutput)
+	}
+	return path, nil
+}
+
+// startDebugging asks the engine to attach a debugger to the program in cmd, giving up after the configured timeout.
+func startDebugging(
+	ctx context.Context, engineClient pulumirpc.EngineClient, cmd *exec.Cmd, opts debuggerOptions,
+) error {
+	timeout := opts.timeout
+	if timeout == 0 {
+		timeout = defaultDebuggerTimeout
+	}
+	// wait for the debugger to be ready
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, errors.New("debugger startup timed out"))
+	defer cancel()
+
+	debugConfig, err := structpb.NewStruct(opts.config(cmd.Process.Pid))
+	if err != nil {
+		return err
+	}
+	_, err = engineClient.StartDebugging(ctx, &pulumirpc.StartDebuggingRequest{
+		Config:  debugConfig,
+		Message: fmt.Sprintf("on process id %d", cmd.Process.Pid),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to start debugging: %w", err)
+	}
+
+	return nil
+}
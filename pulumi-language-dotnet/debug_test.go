@@ -0,0 +1,137 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebuggerOptions(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	opts, err := parseOptions(root, map[string]interface{}{
+		"use-executor": "dotnet",
+		"debugger": map[string]interface{}{
+			"type":              "clr",
+			"justMyCode":        false,
+			"symbolSearchPaths": []interface{}{"symbols", "/opt/symbols"},
+			"timeout":           float64(90),
+			"wait":              true,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":       "Pulumi: Program (Dotnet)",
+		"type":       "clr",
+		"request":    "attach",
+		"processId":  42,
+		"justMyCode": false,
+		"symbolOptions": map[string]interface{}{
+			"searchPaths": []interface{}{filepath.Join(root, "symbols"), "/opt/symbols"},
+		},
+	}, opts.debugger.config(42))
+	assert.Equal(t, []string{"PULUMI_WAIT_FOR_DEBUGGER=true", "PULUMI_DEBUGGER_TIMEOUT=90"}, opts.debugger.env())
+
+	// Without the option, the engine gets the configuration it always did.
+	assert.Equal(t, map[string]interface{}{
+		"name":      "Pulumi: Program (Dotnet)",
+		"type":      "coreclr",
+		"request":   "attach",
+		"processId": 42,
+	}, debuggerOptions{}.config(42))
+	assert.Empty(t, debuggerOptions{}.env())
+
+	opts, err = parseOptions(root, map[string]interface{}{
+		"use-executor": "dotnet",
+		"debugger":     map[string]interface{}{"timeout": "1m30s"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, opts.debugger.timeout)
+
+	for message, debugger := range map[string]interface{}{
+		"debugger option must be a map":                         "coreclr",
+		"debugger.justMyCode option must be a bool":             map[string]interface{}{"justMyCode": "no"},
+		"debugger.symbolSearchPaths option must be a list":      map[string]interface{}{"symbolSearchPaths": "a"},
+		"debugger.timeout option must be a number of seconds":   map[string]interface{}{"timeout": "soon"},
+		`unknown debugger option "justmycode", expected one of`: map[string]interface{}{"justmycode": true},
+		"debugger.timeout option must not be negative":          map[string]interface{}{"timeout": float64(-1)},
+		"debugger.wait option must be a bool":                   map[string]interface{}{"wait": "yes"},
+		"debugger.type option must be a string":                 map[string]interface{}{"type": true},
+		"debugger.symbolSearchPaths option must be a list of strings": map[string]interface{}{
+			"symbolSearchPaths": []interface{}{1.0},
+		},
+	} {
+		_, err := parseOptions(root, map[string]interface{}{"use-executor": "dotnet", "debugger": debugger})
+		assert.ErrorContains(t, err, message)
+	}
+}
+
+func TestTargetPath(t *testing.T) {
+	t.Parallel()
+
+	path, err := targetPath("Welcome to .NET!\n" + filepath.Join(string(filepath.Separator), "src", "App.dll") + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(string(filepath.Separator), "src", "App.dll"), path)
+
+	_, err = targetPath("\n")
+	assert.ErrorContains(t, err, "could not determine the path of the built assembly")
+}
+
+func TestBuildDebuggingDLL(t *testing.T) {
+	t.Parallel()
+
+	dotnet, err := exec.LookPath("dotnet")
+	if err != nil {
+		t.Skip("dotnet is not installed")
+	}
+
+	// The assembly is named differently from the project, which used to be guessed wrong.
+	program := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(program, "Infra.csproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <OutputType>Exe</OutputType>
+    <TargetFramework>net8.0</TargetFramework>
+    <AssemblyName>Company.Infra</AssemblyName>
+  </PropertyGroup>
+</Project>`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(program, "Program.cs"), []byte(`System.Console.WriteLine();`), 0o600))
+
+	path, err := buildDebuggingDLL(context.Background(), dotnetOptions{dotnetExec: dotnet}, program, ".")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(program, "bin", "pulumi-debugging", "Company.Infra.dll"), path)
+	assert.FileExists(t, path)
+
+	// A project that targets several frameworks is debugged with the first of them.
+	multi := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(multi, "Multi.csproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <OutputType>Exe</OutputType>
+    <TargetFrameworks>net8.0;net7.0</TargetFrameworks>
+  </PropertyGroup>
+</Project>`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(multi, "Program.cs"), []byte(`System.Console.WriteLine();`), 0o600))
+	path, err = buildDebuggingDLL(context.Background(), dotnetOptions{dotnetExec: dotnet}, multi, ".")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(multi, "bin", "pulumi-debugging", "Multi.dll"), path)
+}
@@ -0,0 +1,136 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// maxSummarizedErrors is how many errors are included in the error returned for a failed command, the rest are only
+// sent to the engine.
+const maxSummarizedErrors = 3
+
+// Matches MSBuild's canonical error format, used by MSBuild itself, the compilers and NuGet:
+//
+//	origin[(location)]: [subcategory] error|warning [code]: message [project]
+//
+// The origin is usually a file, but can also be a tool name such as `CSC` or `MSBUILD`.
+var msbuildDiagnosticRegexp = regexp.MustCompile(`^\s*(.+?)(?:\(([\d,\-]+)\))?\s*:\s*(?:[^:]*\s)?` +
+	`(error|warning)\s*([A-Za-z]+\d+)?\s*:\s*(.*?)(?:\s+\[([^\]]+)\])?\s*$`)
+
+// msbuildDiagnostic is an error or warning reported by a build.
+type msbuildDiagnostic struct {
+	Origin   string
+	Location string
+	Severity string
+	Code     string
+	Message  string
+	Project  string
+}
+
+func (d msbuildDiagnostic) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.Origin)
+	if d.Location != "" {
+		fmt.Fprintf(&sb, "(%s)", d.Location)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(d.Severity)
+	if d.Code != "" {
+		sb.WriteString(" ")
+		sb.WriteString(d.Code)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(d.Message)
+	return sb.String()
+}
+
+func (d msbuildDiagnostic) logSeverity() pulumirpc.LogSeverity {
+	if d.Severity == "error" {
+		return pulumirpc.LogSeverity_ERROR
+	}
+	return pulumirpc.LogSeverity_WARNING
+}
+
+// parseMSBuildDiagnostics returns the errors and warnings in the output of a dotnet command, in the order they were
+// first reported.  MSBuild repeats them in its summary at the end of a build, so duplicates are removed.
+func parseMSBuildDiagnostics(output string) []msbuildDiagnostic {
+	var diagnostics []msbuildDiagnostic
+	seen := map[msbuildDiagnostic]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		m := msbuildDiagnosticRegexp.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		d := msbuildDiagnostic{
+			Origin:   m[1],
+			Location: m[2],
+			Severity: m[3],
+			Code:     m[4],
+			Message:  m[5],
+			Project:  m[6],
+		}
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// logMSBuildDiagnostics sends each diagnostic to the engine.
+func logMSBuildDiagnostics(
+	ctx context.Context, engineClient pulumirpc.EngineClient, diagnostics []msbuildDiagnostic,
+) error {
+	for _, d := range diagnostics {
+		_, err := engineClient.Log(ctx, &pulumirpc.LogRequest{
+			Message:  strings.ToValidUTF8(d.String(), "�"),
+			Severity: d.logSeverity(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summarizeMSBuildErrors returns a description of the first few errors in diagnostics, or "" if there are none.
+func summarizeMSBuildErrors(diagnostics []msbuildDiagnostic) string {
+	var errs []string
+	for _, d := range diagnostics {
+		if d.Severity == "error" {
+			errs = append(errs, d.String())
+		}
+	}
+	if len(errs) == 0 {
+		return ""
+	}
+
+	summary := errs
+	if len(summary) > maxSummarizedErrors {
+		summary = summary[:maxSummarizedErrors]
+	}
+	result := "  " + strings.Join(summary, "\n  ")
+	if more := len(errs) - len(summary); more > 0 {
+		result += fmt.Sprintf("\n  ... and %d more", more)
+	}
+	return result
+}
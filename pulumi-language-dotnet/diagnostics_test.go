@@ -0,0 +1,172 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestParseMSBuildDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	output := "  Determining projects to restore...\n" +
+		"/src/Infra.csproj : warning NU1603: Pulumi 3.60.0 depends on Grpc (>= 2.0) but Grpc 2.0 was not found.\n" +
+		"  Restored /src/Infra.csproj (in 1.2 sec).\n" +
+		"/src/Program.cs(12,5): error CS1002: ; expected [/src/Infra.csproj]\r\n" +
+		`C:\src\Stack.cs(3,1,3,9): error CS0246: The type or namespace name 'Foo' could not be found [C:\src\Infra.csproj]` +
+		"\n" +
+		"CSC : error CS5001: Program does not contain a static 'Main' method suitable for an entry point\n" +
+		"\n" +
+		"Build FAILED.\n" +
+		"\n" +
+		"/src/Infra.csproj : warning NU1603: Pulumi 3.60.0 depends on Grpc (>= 2.0) but Grpc 2.0 was not found.\n" +
+		"/src/Program.cs(12,5): error CS1002: ; expected [/src/Infra.csproj]\n" +
+		"    1 Warning(s)\n" +
+		"    3 Error(s)\n"
+
+	diagnostics := parseMSBuildDiagnostics(output)
+	assert.Equal(t, []msbuildDiagnostic{
+		{
+			Origin:   "/src/Infra.csproj",
+			Severity: "warning",
+			Code:     "NU1603",
+			Message:  "Pulumi 3.60.0 depends on Grpc (>= 2.0) but Grpc 2.0 was not found.",
+		},
+		{
+			Origin:   "/src/Program.cs",
+			Location: "12,5",
+			Severity: "error",
+			Code:     "CS1002",
+			Message:  "; expected",
+			Project:  "/src/Infra.csproj",
+		},
+		{
+			Origin:   `C:\src\Stack.cs`,
+			Location: "3,1,3,9",
+			Severity: "error",
+			Code:     "CS0246",
+			Message:  "The type or namespace name 'Foo' could not be found",
+			Project:  `C:\src\Infra.csproj`,
+		},
+		{
+			Origin:   "CSC",
+			Severity: "error",
+			Code:     "CS5001",
+			Message:  "Program does not contain a static 'Main' method suitable for an entry point",
+		},
+	}, diagnostics)
+
+	assert.Equal(t, "/src/Program.cs(12,5): error CS1002: ; expected", diagnostics[1].String())
+}
+
+func TestSummarizeMSBuildErrors(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", summarizeMSBuildErrors([]msbuildDiagnostic{
+		{Origin: "/src/Infra.csproj", Severity: "warning", Code: "NU1603", Message: "Grpc 2.0 was not found."},
+	}))
+
+	var diagnostics []msbuildDiagnostic
+	for _, code := range []string{"CS0001", "CS0002", "CS0003", "CS0004", "CS0005"} {
+		diagnostics = append(diagnostics,
+			msbuildDiagnostic{Origin: "Program.cs", Severity: "error", Code: code, Message: "oops"})
+	}
+	assert.Equal(t, "  Program.cs: error CS0001: oops\n"+
+		"  Program.cs: error CS0002: oops\n"+
+		"  Program.cs: error CS0003: oops\n"+
+		"  ... and 2 more", summarizeMSBuildErrors(diagnostics))
+}
+
+// logRecordingEngine is an engine that records what it's asked to log.
+type logRecordingEngine struct {
+	pulumirpc.EngineClient
+
+	mu   sync.Mutex
+	logs []*pulumirpc.LogRequest
+}
+
+func (e *logRecordingEngine) Log(
+	_ context.Context, req *pulumirpc.LogRequest, _ ...grpc.CallOption,
+) (*emptypb.Empty, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.logs = append(e.logs, req)
+	return &emptypb.Empty{}, nil
+}
+
+func TestRunDiscoveryCommandDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the dotnet executable")
+	}
+
+	// A dotnet that fails with a compiler error the first failures times it's run.
+	fakeDotnet := func(failures int) string {
+		dir := t.TempDir()
+		dotnet := filepath.Join(dir, "dotnet")
+		script := "#!/bin/sh\n" +
+			"echo run >> \"$PWD/runs\"\n" +
+			"if [ \"$(wc -l < \"$PWD/runs\")\" -le " + strconv.Itoa(failures) + " ]; then\n" +
+			"  echo 'Program.cs(3,1): error CS1002: ; expected [/program/Infra.csproj]'\n" +
+			"  exit 1\n" +
+			"fi\n" +
+			"echo listed\n"
+		require.NoError(t, os.WriteFile(dotnet, []byte(script), 0o700))
+		return dir
+	}
+	errorLogs := func(engine *logRecordingEngine) []string {
+		var logs []string
+		for _, log := range engine.logs {
+			if log.Severity == pulumirpc.LogSeverity_ERROR {
+				logs = append(logs, log.Message)
+			}
+		}
+		return logs
+	}
+
+	t.Run("succeeds on retry", func(t *testing.T) {
+		t.Parallel()
+
+		dir := fakeDotnet(1)
+		engine := &logRecordingEngine{}
+		output, err := runDiscoveryCommand(t.Context(), filepath.Join(dir, "dotnet"), engine, []string{"list"}, dir)
+		require.NoError(t, err)
+		assert.Equal(t, "listed\n", output)
+		assert.Empty(t, errorLogs(engine))
+	})
+
+	t.Run("fails every attempt", func(t *testing.T) {
+		t.Parallel()
+
+		dir := fakeDotnet(3)
+		engine := &logRecordingEngine{}
+		_, err := runDiscoveryCommand(t.Context(), filepath.Join(dir, "dotnet"), engine, []string{"list"}, dir)
+		assert.ErrorContains(t, err, "Program.cs(3,1): error CS1002: ; expected")
+		assert.Equal(t, []string{"Program.cs(3,1): error CS1002: ; expected"}, errorLogs(engine))
+	})
+}
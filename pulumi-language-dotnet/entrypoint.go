@@ -0,0 +1,282 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Matches the `Project("{type}") = "Name", "path\to\Name.csproj", "{guid}"` lines of a `.sln` file.
+var slnProjectRegexp = regexp.MustCompile(`(?m)^Project\("[^"]*"\)\s*=\s*"([^"]*)"\s*,\s*"([^"]*)"`)
+
+// Matches references to the Pulumi SDK, as a package or as a project.
+var pulumiReferenceRegexp = regexp.MustCompile(
+	`<(?:PackageReference\s+Include\s*=\s*"Pulumi(?:\.FSharp)?"|` +
+		`ProjectReference\s+Include\s*=\s*"(?:[^"]*[\\/])?Pulumi(?:\.FSharp)?\.[cf]sproj")`)
+
+// ambiguousProjectError is returned when there's more than one project that could be the Pulumi program.
+type ambiguousProjectError struct {
+	// Where the projects were found, a directory or a solution file.
+	source string
+	// The directory the candidates are relative to.
+	dir string
+	// The candidate project files.
+	candidates []string
+}
+
+func (e *ambiguousProjectError) Error() string {
+	return "found several projects in " + e.source + " that could be the Pulumi program: " +
+		strings.Join(e.candidates, ", ") + "; set `main` in Pulumi.yaml, or the `project` runtime option, " +
+		"to the one to run"
+}
+
+// resolveProject returns the project file of the Pulumi program in programDirectory.  The entry point, from `main` in
+// Pulumi.yaml or the `project` option, can be a project file, a solution file, a directory containing either, the
+// name of a project in the solution, or a file-based program.  Without one, the program directory is searched.
+func resolveProject(programDirectory, entryPoint string) (string, error) {
+	if entryPoint == "" || entryPoint == "." {
+		return resolveProjectInDirectory(programDirectory)
+	}
+
+	path := entryPoint
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(programDirectory, entryPoint)
+	}
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return resolveProjectInDirectory(path)
+	case err == nil && isSolutionFile(path):
+		return resolveProjectInSolution(path)
+	case err == nil && (isProjectFile(path) || isFileBasedProgram(path)):
+		return filepath.Abs(path)
+	case err == nil:
+		return "", errors.Errorf("%s is not a project file, solution file, C# file or directory", path)
+	case !os.IsNotExist(err):
+		return "", err
+	case isProjectFile(path):
+		return "", errors.Errorf("project file %s does not exist", path)
+	case isSolutionFile(path):
+		return "", errors.Errorf("solution file %s does not exist", path)
+	case isFileBasedProgram(path):
+		return "", errors.Errorf("program file %s does not exist", path)
+	}
+
+	// Not a path, so it has to be the name of a project in the solution.
+	solutions, err := listSolutionFiles(programDirectory)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, solution := range solutions {
+		projects, err := solutionProjects(solution)
+		if err != nil {
+			return "", err
+		}
+		for _, project := range projects {
+			name := strings.TrimSuffix(filepath.Base(project), filepath.Ext(project))
+			if strings.EqualFold(name, entryPoint) {
+				return project, nil
+			}
+			names = append(names, name)
+		}
+	}
+	if len(solutions) == 0 {
+		return "", errors.Errorf("%s does not exist, and there is no solution file in %s to find a project named %s in",
+			path, programDirectory, entryPoint)
+	}
+	sort.Strings(names)
+	return "", errors.Errorf("%s does not exist, and no project in the solution is named %s; the projects are: %s",
+		path, entryPoint, strings.Join(names, ", "))
+}
+
+// resolveProjectInDirectory returns the Pulumi program among the project files in dir or, if there are none, among
+// the projects of the solution in dir.
+func resolveProjectInDirectory(dir string) (string, error) {
+	names, err := listProjectFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(names) > 0 {
+		projects := make([]string, len(names))
+		for i, name := range names {
+			projects[i] = filepath.Join(dir, name)
+		}
+		project, err := choosePulumiProgram(dir, dir, projects)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Abs(project)
+	}
+
+	solutions, err := listSolutionFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	switch len(solutions) {
+	case 0:
+		return "", errors.Errorf("no project or solution file found in %s", dir)
+	case 1:
+		return resolveProjectInSolution(solutions[0])
+	default:
+		for i := range solutions {
+			solutions[i] = filepath.Base(solutions[i])
+		}
+		return "", errors.Errorf("found several solution files in %s: %s; set `main` in Pulumi.yaml to the one to use",
+			dir, strings.Join(solutions, ", "))
+	}
+}
+
+// resolveProjectInSolution returns the Pulumi program among the projects of a solution.
+func resolveProjectInSolution(solution string) (string, error) {
+	projects, err := solutionProjects(solution)
+	if err != nil {
+		return "", err
+	}
+	if len(projects) == 0 {
+		return "", errors.Errorf("solution %s has no projects", solution)
+	}
+	return choosePulumiProgram(solution, filepath.Dir(solution), projects)
+}
+
+// choosePulumiProgram picks the project that is the Pulumi program: the executable one that references Pulumi.
+// Either test is only applied if some project passes it, as the project may get its output type or the reference
+// from elsewhere, such as a Directory.Build.props.  dir is what candidates are listed relative to in errors.
+func choosePulumiProgram(source, dir string, projects []string) (string, error) {
+	candidates := projects
+	for _, test := range []func(string) bool{isExecutableProject, referencesPulumi} {
+		if len(candidates) == 1 {
+			break
+		}
+		var passed []string
+		for _, project := range candidates {
+			if test(project) {
+				passed = append(passed, project)
+			}
+		}
+		if len(passed) > 0 {
+			candidates = passed
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		if rel, err := filepath.Rel(dir, candidate); err == nil {
+			candidate = rel
+		}
+		names[i] = candidate
+	}
+	return "", &ambiguousProjectError{source: source, dir: dir, candidates: names}
+}
+
+// isExecutableProject returns whether a project builds an executable rather than a library.
+func isExecutableProject(projectFile string) bool {
+	switch strings.ToLower(projectProperty(projectFile, "OutputType")) {
+	case "exe", "winexe":
+		return true
+	default:
+		return false
+	}
+}
+
+// referencesPulumi returns whether a project references the Pulumi SDK itself.
+func referencesPulumi(projectFile string) bool {
+	data, err := os.ReadFile(projectFile)
+	return err == nil && pulumiReferenceRegexp.Match(data)
+}
+
+func isSolutionFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".sln", ".slnx":
+		return true
+	default:
+		return false
+	}
+}
+
+// listSolutionFiles returns the solution files directly inside dir, sorted by name.
+func listSolutionFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var solutions []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isSolutionFile(entry.Name()) {
+			solutions = append(solutions, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(solutions)
+	return solutions, nil
+}
+
+// solutionProjects returns the absolute paths of the .NET projects in a `.sln` or `.slnx` solution, in the order the
+// solution lists them.  Solution folders and other kinds of projects are left out.
+func solutionProjects(solution string) ([]string, error) {
+	data, err := os.ReadFile(solution)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if filepath.Ext(solution) == ".slnx" {
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, errors.Wrapf(err, "could not parse %s", solution)
+			}
+			if element, ok := token.(xml.StartElement); ok && element.Name.Local == "Project" {
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "Path" {
+						paths = append(paths, attr.Value)
+					}
+				}
+			}
+		}
+	} else {
+		for _, m := range slnProjectRegexp.FindAllStringSubmatch(string(data), -1) {
+			paths = append(paths, m[2])
+		}
+	}
+
+	solutionDir, err := filepath.Abs(filepath.Dir(solution))
+	if err != nil {
+		return nil, err
+	}
+	var projects []string
+	for _, path := range paths {
+		// Solutions written on Windows use backslashes, but they work everywhere.
+		path = filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+		if isProjectFile(path) {
+			projects = append(projects, filepath.Join(solutionDir, path))
+		}
+	}
+	return projects, nil
+}
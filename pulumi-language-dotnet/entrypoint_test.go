@@ -0,0 +1,163 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testExeProject = `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <OutputType>Exe</OutputType>
+  </PropertyGroup>
+</Project>`
+	testPulumiProject = `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <OutputType>Exe</OutputType>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Pulumi" Version="3.*" />
+  </ItemGroup>
+</Project>`
+	testLibraryProject = `<Project Sdk="Microsoft.NET.Sdk" />`
+)
+
+func TestResolveProject(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFile := func(path, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(contents), 0o600))
+	}
+
+	// No entry point, ".", and a project file all resolve to the program's project, as does its directory.
+	writeFile("program/Infra.csproj", testExeProject)
+	for _, entryPoint := range []string{"", ".", "Infra.csproj"} {
+		project, err := resolveProject(filepath.Join(root, "program"), entryPoint)
+		require.NoError(t, err, entryPoint)
+		assert.Equal(t, filepath.Join(root, "program", "Infra.csproj"), project, entryPoint)
+	}
+	project, err := resolveProject(root, "program")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "program", "Infra.csproj"), project)
+	_, err = resolveProject(filepath.Join(root, "program"), "Wrong.csproj")
+	assert.EqualError(t, err, "project file "+filepath.Join(root, "program", "Wrong.csproj")+" does not exist")
+
+	// A single project wins over the solution next to it.
+	writeFile("sln/Sln.csproj", testLibraryProject)
+	writeFile("sln/Sln.sln", `Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Other", "..\Other.csproj", "{1}"`)
+	project, err = resolveProject(filepath.Join(root, "sln"), ".")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "sln", "Sln.csproj"), project)
+
+	// The executable project of a solution, with Windows paths, is the program.
+	writeFile("nested/Sln.sln", `Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "src", "src", "{0}"
+EndProject
+Project("{9A19103F-16F7-4668-BE54-9A1E7A4F7556}") = "Program", "src\Program\Program.csproj", "{1}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Library", "src\Library\Library.csproj", "{2}"
+EndProject
+`)
+	writeFile("nested/src/Program/Program.csproj", testExeProject)
+	writeFile("nested/src/Library/Library.csproj", testLibraryProject)
+	for _, entryPoint := range []string{".", "Sln.sln", "Program", "program"} {
+		project, err = resolveProject(filepath.Join(root, "nested"), entryPoint)
+		require.NoError(t, err, entryPoint)
+		assert.Equal(t, filepath.Join(root, "nested", "src", "Program", "Program.csproj"), project, entryPoint)
+	}
+	// Or it can be named explicitly.
+	project, err = resolveProject(filepath.Join(root, "nested"), "Library")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "nested", "src", "Library", "Library.csproj"), project)
+	_, err = resolveProject(filepath.Join(root, "nested"), "Tests")
+	assert.ErrorContains(t, err, "no project in the solution is named Tests; the projects are: Library, Program")
+	_, err = resolveProject(filepath.Join(root, "nested"), "Missing.csproj")
+	assert.ErrorContains(t, err, "project file "+filepath.Join(root, "nested", "Missing.csproj")+" does not exist")
+
+	// Among several executables, the one that references Pulumi is the program, from a .slnx too.
+	writeFile("slnx/Infra.slnx", `<Solution>
+  <Folder Name="/src/">
+    <Project Path="src/Infra/Infra.csproj" />
+    <Project Path="src/Tool/Tool.fsproj" />
+  </Folder>
+  <Project Path="build/Build.proj" />
+</Solution>`)
+	writeFile("slnx/src/Infra/Infra.csproj", testPulumiProject)
+	writeFile("slnx/src/Tool/Tool.fsproj", testExeProject)
+	project, err = resolveProject(filepath.Join(root, "slnx"), ".")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "slnx", "src", "Infra", "Infra.csproj"), project)
+
+	// When that doesn't settle it, the candidates are listed.
+	writeFile("slnx/src/Tool/Tool.fsproj", testPulumiProject)
+	_, err = resolveProject(filepath.Join(root, "slnx"), ".")
+	var ambiguous *ambiguousProjectError
+	require.ErrorAs(t, err, &ambiguous)
+	assert.Equal(t, []string{
+		filepath.Join("src", "Infra", "Infra.csproj"),
+		filepath.Join("src", "Tool", "Tool.fsproj"),
+	}, ambiguous.candidates)
+	assert.ErrorContains(t, err, "set `main` in Pulumi.yaml, or the `project` runtime option, to the one to run")
+
+	writeFile("slnx/Other.sln", "")
+	_, err = resolveProject(filepath.Join(root, "slnx"), ".")
+	assert.ErrorContains(t, err, "found several solution files in "+filepath.Join(root, "slnx")+": Infra.slnx, Other.sln")
+
+	// A file-based program is its own project.
+	writeFile("file/Program.cs", "#:package Pulumi@3.*\n")
+	project, err = resolveProject(filepath.Join(root, "file"), "Program.cs")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "file", "Program.cs"), project)
+	_, err = resolveProject(filepath.Join(root, "file"), "Missing.cs")
+	assert.ErrorContains(t, err, "program file "+filepath.Join(root, "file", "Missing.cs")+" does not exist")
+
+	_, err = resolveProject(filepath.Join(root, "nested", "src"), ".")
+	assert.ErrorContains(t, err, "no project or solution file found in "+filepath.Join(root, "nested", "src"))
+}
+
+func TestRuntimeOptionsPromptsSolution(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Infra.slnx"), []byte(`<Solution>
+  <Project Path="Dev/Dev.csproj" />
+  <Project Path="Prod/Prod.csproj" />
+</Solution>`), 0o600))
+	for _, name := range []string{"Dev", "Prod"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, name+".csproj"), []byte(testPulumiProject), 0o600))
+	}
+
+	prompts, err := runtimeOptionsPrompts(dir, ".", map[string]interface{}{})
+	require.NoError(t, err)
+	require.Len(t, prompts, 1)
+	assert.Equal(t, "project", prompts[0].Key)
+	assert.Equal(t, "Dev/Dev.csproj", prompts[0].Default.StringValue)
+	require.Len(t, prompts[0].Choices, 2)
+	assert.Equal(t, "Prod/Prod.csproj", prompts[0].Choices[1].StringValue)
+
+	// Once chosen, a project with one framework and the default configurations needs nothing else.
+	prompts, err = runtimeOptionsPrompts(dir, ".", map[string]interface{}{"project": "Prod/Prod.csproj"})
+	require.NoError(t, err)
+	assert.Empty(t, prompts)
+}
@@ -0,0 +1,250 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// File-based programs are a single `.cs` file that `dotnet run` builds without a project file, from .NET 10.  What a
+// project file would say goes in `#:` directives at the top of the file instead:
+//
+//	#:sdk Microsoft.NET.Sdk.Web
+//	#:package Pulumi@3.*
+//	#:property TargetFramework=net10.0
+//	#:project ../Sdk
+//
+// The host treats the file as the program's project wherever it can, so it is what gets built, run and searched for
+// packages.
+
+// fileDirective is one `#:kind value` directive of a file-based program.
+type fileDirective struct {
+	kind  string
+	value string
+}
+
+// isFileBasedProgram returns whether path is a file-based program rather than a project file.
+func isFileBasedProgram(path string) bool {
+	return filepath.Ext(path) == ".cs"
+}
+
+// readFileDirectives returns the directives of a file-based program.  They have to come before any code, so only a
+// `#!` line, blank lines and comments can precede them.
+func readFileDirectives(program string) ([]fileDirective, error) {
+	data, err := os.ReadFile(program)
+	if err != nil {
+		return nil, err
+	}
+	var directives []fileDirective
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, "//"):
+			continue
+		case i == 0 && strings.HasPrefix(line, "#!"):
+			continue
+		case strings.HasPrefix(line, "#:"):
+			kind, value, _ := strings.Cut(line[2:], " ")
+			directives = append(directives, fileDirective{kind: kind, value: strings.TrimSpace(value)})
+		default:
+			return directives, nil
+		}
+	}
+	return directives, nil
+}
+
+// fileDirectiveProperty returns the value of an MSBuild property a file-based program sets with `#:property`, and
+// whether it sets it at all.
+func fileDirectiveProperty(program, name string) (string, bool) {
+	directives, err := readFileDirectives(program)
+	if err != nil {
+		return "", false
+	}
+	value, found := "", false
+	for _, directive := range directives {
+		if directive.kind != "property" {
+			continue
+		}
+		// Early previews of .NET 10 separated the name and value with a space.
+		key, v, ok := strings.Cut(directive.value, "=")
+		if !ok {
+			key, v, _ = strings.Cut(directive.value, " ")
+		}
+		if strings.EqualFold(strings.TrimSpace(key), name) {
+			// Like in a project file, the last definition wins.
+			value, found = strings.TrimSpace(v), true
+		}
+	}
+	return value, found
+}
+
+// fileProgramReferences returns the paths of the projects a file-based program references with `#:project`, which
+// can name the project file or the directory it is in.
+func fileProgramReferences(program string) ([]string, error) {
+	directives, err := readFileDirectives(program)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, directive := range directives {
+		if directive.kind != "project" || directive.value == "" {
+			continue
+		}
+		ref := filepath.FromSlash(strings.ReplaceAll(directive.value, `\`, "/"))
+		if !filepath.IsAbs(ref) {
+			ref = filepath.Join(filepath.Dir(program), ref)
+		}
+		if info, err := os.Stat(ref); err == nil && info.IsDir() {
+			if projectFile, err := findProjectFile(ref); err == nil {
+				ref = projectFile
+			}
+		}
+		refs = append(refs, filepath.Clean(ref))
+	}
+	return refs, nil
+}
+
+// fileProgramPackages returns the packages a file-based program references with `#:package`, as `dotnet list
+// package` would for a project, which it can't do for file-based programs.  Restoring them leaves no assets file next
+// to the program either, so the versions are resolved against what has been restored to packageDirs.  Only the
+// top-level packages are known this way.
+func fileProgramPackages(program string, packageDirs []string) (*packageList, error) {
+	directives, err := readFileDirectives(program)
+	if err != nil {
+		return nil, err
+	}
+	framework, _ := fileDirectiveProperty(program, "TargetFramework")
+	packages := frameworkPackages{Framework: framework}
+	for _, directive := range directives {
+		if directive.kind != "package" || directive.value == "" {
+			continue
+		}
+		id, requested, ok := strings.Cut(directive.value, "@")
+		if !ok {
+			// Early previews of .NET 10 separated the id and version with a space.
+			id, requested, _ = strings.Cut(directive.value, " ")
+		}
+		id, requested = strings.TrimSpace(id), strings.TrimSpace(requested)
+		resolved := restoredPackageVersion(packageDirs, id, requested)
+		if resolved == "" {
+			resolved = requested
+		}
+		packages.Packages = append(packages.Packages, packageReference{
+			ID:               id,
+			RequestedVersion: requested,
+			ResolvedVersion:  resolved,
+		})
+	}
+	return &packageList{Projects: []projectPackages{{Path: program, Frameworks: []frameworkPackages{packages}}}}, nil
+}
+
+// restoredPackageVersion returns the version of a package restored to packageDirs that best matches the version a
+// `#:package` directive asks for: that exact version, or the highest restored one matching a floating version such
+// as `3.*`.  Ranges, and directives without a version, match the highest restored one.  It returns "" if there is
+// no match.
+func restoredPackageVersion(packageDirs []string, id, requested string) string {
+	requested = strings.TrimSpace(requested)
+	exact := requested != "" && !strings.ContainsAny(requested, "*[]()")
+	prefix := ""
+	if strings.HasSuffix(requested, "*") && !strings.ContainsAny(requested, "[]()") {
+		prefix = strings.ToLower(strings.TrimSuffix(requested, "*"))
+	}
+
+	best := ""
+	for _, dir := range packageDirs {
+		entries, err := os.ReadDir(filepath.Join(dir, strings.ToLower(id)))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			version := entry.Name()
+			switch {
+			case !entry.IsDir():
+				continue
+			case exact:
+				if strings.EqualFold(version, requested) {
+					return version
+				}
+				continue
+			case !strings.HasPrefix(version, prefix):
+				continue
+			}
+			if best == "" || compareNugetVersions(version, best) > 0 {
+				best = version
+			}
+		}
+	}
+	return best
+}
+
+// compareNugetVersions orders two NuGet versions, falling back to comparing them as strings when they aren't semver.
+func compareNugetVersions(a, b string) int {
+	av, aErr := semver.ParseTolerant(a)
+	bv, bErr := semver.ParseTolerant(b)
+	if aErr != nil || bErr != nil {
+		return strings.Compare(a, b)
+	}
+	return av.Compare(bv)
+}
+
+// addFileDirective adds a `#:kind value` directive to the source of a file-based program, after its existing
+// directives.  A directive of the same kind that matches, such as one for the same package, is replaced instead, and
+// by default only the same directive matches.
+func addFileDirective(contents, kind, value string, matches func(existing string) bool) string {
+	directive := "#:" + kind + " " + value
+	if matches == nil {
+		matches = func(existing string) bool { return existing == value }
+	}
+
+	lines := strings.SplitAfter(contents, "\n")
+	insertAt := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if i == 0 {
+			trimmed = strings.TrimPrefix(trimmed, "\ufeff")
+		}
+		if existingKind, existing, ok := strings.Cut(strings.TrimPrefix(trimmed, "#:"), " "); ok &&
+			strings.HasPrefix(trimmed, "#:") && existingKind == kind && matches(strings.TrimSpace(existing)) {
+			if trimmed == directive {
+				return contents
+			}
+			return strings.Replace(contents, trimmed, directive, 1)
+		}
+		if strings.HasPrefix(trimmed, "#:") || (i == 0 && strings.HasPrefix(trimmed, "#!")) {
+			insertAt = i + 1
+		} else if trimmed != "" && !strings.HasPrefix(trimmed, "//") {
+			break
+		}
+	}
+
+	newline := "\n"
+	if strings.Contains(contents, "\r\n") {
+		newline = "\r\n"
+	}
+	if insertAt > 0 && !strings.HasSuffix(lines[insertAt-1], "\n") {
+		lines[insertAt-1] += newline
+	}
+	result := append([]string{}, lines[:insertAt]...)
+	result = append(result, directive+newline)
+	result = append(result, lines[insertAt:]...)
+	return strings.Join(result, "")
+}
@@ -0,0 +1,127 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFileProgram = "\ufeff#!/usr/bin/env dotnet\n" +
+	`// An infrastructure program in one file.
+
+#:package Pulumi@3.*
+#:package Pulumi.Random@4.16.0
+#:package Pulumi.Tls
+#:property TargetFramework=net10.0
+#:property RestorePackagesPath=$(MSBuildProjectDirectory)/packages
+#:project ../Sdk
+
+using Pulumi;
+
+#:package NotADirective@1.0.0
+
+return await Deployment.RunAsync(() => {});
+`
+
+func TestReadFileDirectives(t *testing.T) {
+	t.Parallel()
+
+	program := filepath.Join(t.TempDir(), "Program.cs")
+	require.NoError(t, os.WriteFile(program, []byte(testFileProgram), 0o600))
+
+	directives, err := readFileDirectives(program)
+	require.NoError(t, err)
+	assert.Equal(t, []fileDirective{
+		{kind: "package", value: "Pulumi@3.*"},
+		{kind: "package", value: "Pulumi.Random@4.16.0"},
+		{kind: "package", value: "Pulumi.Tls"},
+		{kind: "property", value: "TargetFramework=net10.0"},
+		{kind: "property", value: "RestorePackagesPath=$(MSBuildProjectDirectory)/packages"},
+		{kind: "project", value: "../Sdk"},
+	}, directives)
+
+	assert.Equal(t, []string{"net10.0"}, projectTargetFrameworks(program))
+	assert.Equal(t, filepath.Join(filepath.Dir(program), "packages"), projectProperty(program, "RestorePackagesPath"))
+	assert.Equal(t, "Program", projectAssemblyName(program))
+}
+
+func TestFileProgramPackages(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	program := filepath.Join(root, "Program.cs")
+	require.NoError(t, os.WriteFile(program, []byte(testFileProgram), 0o600))
+	packages := filepath.Join(root, "packages")
+	for _, dir := range []string{
+		"pulumi/3.9.0", "pulumi/3.10.0", "pulumi/4.0.0",
+		"pulumi.random/4.15.0", "pulumi.random/4.16.0",
+		"pulumi.tls/5.0.0", "pulumi.tls/5.1.0",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(packages, filepath.FromSlash(dir)), 0o700))
+	}
+
+	// The package folders come from the program's RestorePackagesPath.
+	packageDirs, err := resolvePackageFolders(program)
+	require.NoError(t, err)
+	require.Equal(t, packages, packageDirs[0])
+
+	list, err := fileProgramPackages(program, packageDirs)
+	require.NoError(t, err)
+	assert.Equal(t, &packageList{Projects: []projectPackages{{
+		Path: program,
+		Frameworks: []frameworkPackages{{
+			Framework: "net10.0",
+			Packages: []packageReference{
+				{ID: "Pulumi", RequestedVersion: "3.*", ResolvedVersion: "3.10.0"},
+				{ID: "Pulumi.Random", RequestedVersion: "4.16.0", ResolvedVersion: "4.16.0"},
+				{ID: "Pulumi.Tls", RequestedVersion: "", ResolvedVersion: "5.1.0"},
+			},
+		}},
+	}}}, list)
+
+	// Versions that haven't been restored don't resolve.
+	assert.Equal(t, "", restoredPackageVersion(packageDirs, "Pulumi.Random", "4.17.0"))
+	assert.Equal(t, "", restoredPackageVersion(packageDirs, "Pulumi.Aws", "7.*"))
+	assert.Equal(t, "4.0.0", restoredPackageVersion(packageDirs, "Pulumi", "4.*"))
+	assert.Equal(t, "3.10.0", fileProgramPulumiSdkVersion(program))
+	require.NoError(t, os.RemoveAll(filepath.Join(packages, "pulumi")))
+	assert.Equal(t, "3.* (requested, not restored)", fileProgramPulumiSdkVersion(program))
+}
+
+func TestAddFileDirective(t *testing.T) {
+	t.Parallel()
+
+	// Directives go after the existing ones.
+	assert.Equal(t, "#!/usr/bin/env dotnet\n#:package Pulumi@3.*\n#:project ../Sdk\n\nusing Pulumi;\n",
+		addFileDirective("#!/usr/bin/env dotnet\n#:package Pulumi@3.*\n\nusing Pulumi;\n", "project", "../Sdk", nil))
+	// Or at the very top, keeping the line endings.
+	assert.Equal(t, "#:package Pulumi@3.*\r\n// Infra\r\nusing Pulumi;\r\n",
+		addFileDirective("// Infra\r\nusing Pulumi;\r\n", "package", "Pulumi@3.*", nil))
+	// Ones that are already there aren't added again, matching ones are replaced.
+	contents := "#:package Pulumi@3.*\n#:package Pulumi.Tls@5.0.0\n"
+	assert.Equal(t, contents, addFileDirective(contents, "package", "Pulumi@3.*", nil))
+	assert.Equal(t, "#:package Pulumi@3.*\n#:package Pulumi.Tls@5.1.0\n",
+		addFileDirective(contents, "package", "Pulumi.Tls@5.1.0", func(existing string) bool {
+			return existing == "Pulumi.Tls@5.0.0"
+		}))
+	// Directives after code aren't directives.
+	assert.Equal(t, "#:project ../Sdk\nusing Pulumi;\n#:project ../Sdk\n",
+		addFileDirective("using Pulumi;\n#:project ../Sdk\n", "project", "../Sdk", nil))
+}
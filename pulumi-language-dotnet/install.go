@@ -0,0 +1,85 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// installPhase is one of the commands `InstallDependencies` runs.
+type installPhase struct {
+	// What the phase does, as reported to the user.
+	description string
+	// The dotnet command, for errors.
+	command string
+	args    []string
+}
+
+// installPhases returns the commands that install the dependencies of project: restoring its packages, restoring
+// any local .NET tools, and then building it the way Run would.
+func (opts dotnetOptions) installPhases(project, programDirectory string) []installPhase {
+	restore := []string{"restore", project}
+	if opts.runtime != "" {
+		restore = append(restore, "--runtime", opts.runtime)
+	}
+	restore = append(restore, opts.propertyArgs()...)
+	if opts.lockedMode || hasPackagesLockFile(project) {
+		// Fail rather than silently update the lock file, so that CI builds what was committed.
+		restore = append(restore, "--locked-mode")
+	}
+	restore = append(restore, opts.nodeReuseArgs()...)
+	phases := []installPhase{{description: "Restoring packages", command: "restore", args: restore}}
+
+	if manifest := findToolManifest(programDirectory); manifest != "" {
+		phases = append(phases, installPhase{
+			description: "Restoring tools from " + manifest,
+			command:     "tool restore",
+			args:        []string{"tool", "restore", "--tool-manifest", manifest},
+		})
+	}
+
+	// Built the way DotnetBuild builds, but without restoring again, which would undo a locked-mode restore.
+	build := append(opts.buildArgs(project), "--no-restore")
+	return append(phases, installPhase{description: "Building", command: "build", args: build})
+}
+
+// hasPackagesLockFile returns whether project, a project file or a directory containing one, has a
+// `packages.lock.json` next to it.
+func hasPackagesLockFile(project string) bool {
+	dir := project
+	if isProjectFile(project) {
+		dir = filepath.Dir(project)
+	}
+	return fileExists(filepath.Join(dir, "packages.lock.json"))
+}
+
+// findToolManifest returns the manifest of the local .NET tools for dir, looking in the same places as `dotnet tool
+// restore` does, or "" if there is none.
+func findToolManifest(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		for _, path := range []string{
+			filepath.Join(d, ".config", "dotnet-tools.json"),
+			filepath.Join(d, "dotnet-tools.json"),
+		} {
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		if parent := filepath.Dir(d); parent == d {
+			return ""
+		}
+	}
+}
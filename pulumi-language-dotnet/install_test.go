@@ -0,0 +1,74 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallPhases(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	program := filepath.Join(root, "program")
+	require.NoError(t, os.MkdirAll(program, 0o700))
+	project := filepath.Join(program, "Infra.csproj")
+	require.NoError(t, os.WriteFile(project, []byte("<Project />"), 0o600))
+
+	opts := dotnetOptions{configuration: "Release", runtime: "linux-x64"}
+	assert.Equal(t, []installPhase{
+		{
+			description: "Restoring packages",
+			command:     "restore",
+			args:        []string{"restore", project, "--runtime", "linux-x64"},
+		},
+		{
+			description: "Building",
+			command:     "build",
+			args:        append(opts.buildArgs(project), "--no-restore"),
+		},
+	}, opts.installPhases(project, program))
+
+	// A lock file or a tool manifest anywhere above the program add to the install.
+	require.NoError(t, os.WriteFile(filepath.Join(program, "packages.lock.json"), []byte("{}"), 0o600))
+	manifest := filepath.Join(root, ".config", "dotnet-tools.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(manifest), 0o700))
+	require.NoError(t, os.WriteFile(manifest, []byte("{}"), 0o600))
+
+	phases := dotnetOptions{}.installPhases(program, program)
+	require.Len(t, phases, 3)
+	assert.Equal(t, []string{"restore", program, "--locked-mode"}, phases[0].args)
+	assert.Equal(t, installPhase{
+		description: "Restoring tools from " + manifest,
+		command:     "tool restore",
+		args:        []string{"tool", "restore", "--tool-manifest", manifest},
+	}, phases[1])
+	assert.Equal(t, "build", phases[2].command)
+	assert.Equal(t, []string{"build", "-nologo", program, "--no-restore"}, phases[2].args)
+
+	// Locked mode can be asked for without a lock file.
+	require.NoError(t, os.Remove(filepath.Join(program, "packages.lock.json")))
+	opts, err := parseOptions("", map[string]interface{}{"use-executor": "dotnet", "lockedMode": true})
+	require.NoError(t, err)
+	assert.Contains(t, opts.installPhases(project, program)[0].args, "--locked-mode")
+
+	_, err = parseOptions("", map[string]interface{}{"use-executor": "dotnet", "lockedMode": "yes"})
+	assert.ErrorContains(t, err, "lockedMode option must be a bool")
+}
@@ -0,0 +1,283 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	dotnetcodegen "github.com/pulumi/pulumi-dotnet/pulumi-language-dotnet/v3/codegen"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// nugetPackageIdentity is the id and version of a NuGet package, from the `.nuspec` at the root of its `.nupkg`.
+type nugetPackageIdentity struct {
+	ID      string `xml:"metadata>id"`
+	Version string `xml:"metadata>version"`
+}
+
+// readNugetPackageIdentity returns the identity of the package in a `.nupkg` file.
+func readNugetPackageIdentity(nupkg string) (nugetPackageIdentity, error) {
+	r, err := zip.OpenReader(nupkg)
+	if err != nil {
+		return nugetPackageIdentity{}, err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if strings.Contains(f.Name, "/") || filepath.Ext(f.Name) != ".nuspec" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nugetPackageIdentity{}, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nugetPackageIdentity{}, err
+		}
+		var identity nugetPackageIdentity
+		if err := xml.Unmarshal(data, &identity); err != nil {
+			return nugetPackageIdentity{}, errors.Wrapf(err, "reading %s in %s", f.Name, nupkg)
+		}
+		if identity.ID == "" || identity.Version == "" {
+			return nugetPackageIdentity{}, errors.Errorf("%s in %s has no package id or version", f.Name, nupkg)
+		}
+		return identity, nil
+	}
+	return nugetPackageIdentity{}, errors.Errorf("%s has no .nuspec", nupkg)
+}
+
+// linkProjectDependency makes the program in projectFile depend on the SDK at path, which is either a directory
+// holding the SDK's project or a prebuilt `.nupkg`.  The project file is edited in place: existing content and
+// formatting are kept, and anything that's already there isn't added again, so linking is idempotent.
+func linkProjectDependency(projectFile, path string) error {
+	info, err := os.Stat(projectFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(projectFile)
+	if err != nil {
+		return err
+	}
+	contents := string(data)
+	projectDir := filepath.Dir(projectFile)
+
+	if strings.EqualFold(filepath.Ext(path), ".nupkg") {
+		identity, err := readNugetPackageIdentity(path)
+		if err != nil {
+			return err
+		}
+		source, err := filepath.Rel(projectDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		source = "$(MSBuildProjectDirectory)/" + filepath.ToSlash(source)
+		contents = addListProperty(contents, "RestoreAdditionalProjectSources", source)
+		if propsFile := dotnetcodegen.CentralPackageVersionsFile(projectDir); propsFile != "" {
+			// The version goes in Directory.Packages.props, a version in the project file would fail the restore.
+			if err := setCentralPackageVersion(propsFile, identity.ID, identity.Version); err != nil {
+				return err
+			}
+			contents = addPackageReference(contents, identity.ID, "")
+		} else {
+			contents = addPackageReference(contents, identity.ID, identity.Version)
+		}
+	} else {
+		sdkProject, err := findProjectFile(path)
+		if err != nil {
+			return err
+		}
+		refs, err := projectReferences(projectFile)
+		if err != nil {
+			return err
+		}
+		referenced := false
+		for _, ref := range refs {
+			referenced = referenced || sameFile(ref, sdkProject)
+		}
+		if !referenced {
+			rel, err := filepath.Rel(projectDir, sdkProject)
+			if err != nil {
+				return err
+			}
+			// Written the way `dotnet add reference` writes them, which works on every platform.
+			rel = strings.ReplaceAll(filepath.ToSlash(rel), "/", `\`)
+			contents = dotnetcodegen.AddProjectElement(contents, "ItemGroup", "ProjectReference",
+				`<ProjectReference Include="`+xmlAttributeEscape(rel)+`" />`)
+		}
+
+		// The SDK's sources would otherwise be compiled into the program as well.
+		sdkDir, err := filepath.Rel(projectDir, filepath.Dir(sdkProject))
+		if err != nil {
+			return err
+		}
+		if sdkDir != "." && !strings.HasPrefix(sdkDir, "..") {
+			contents = addListProperty(contents, "DefaultItemExcludes", filepath.ToSlash(sdkDir)+"/**")
+		}
+	}
+
+	if contents == string(data) {
+		logging.V(5).Infof("%s already depends on %s", projectFile, path)
+		return nil
+	}
+	return os.WriteFile(projectFile, []byte(contents), info.Mode().Perm())
+}
+
+// setCentralPackageVersion pins the version of a package in propsFile, a `Directory.Packages.props`.
+func setCentralPackageVersion(propsFile, id, version string) error {
+	info, err := os.Stat(propsFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(propsFile)
+	if err != nil {
+		return err
+	}
+	contents := dotnetcodegen.SetCentralPackageVersion(data, id, version)
+	if bytes.Equal(contents, data) {
+		return nil
+	}
+	return os.WriteFile(propsFile, contents, info.Mode().Perm())
+}
+
+// linkFileProgramDependency is linkProjectDependency for a file-based program, which depends on the SDK through
+// `#:package` or `#:project` directives instead.
+func linkFileProgramDependency(program, path string) error {
+	info, err := os.Stat(program)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(program)
+	if err != nil {
+		return err
+	}
+	contents := string(data)
+	programDir := filepath.Dir(program)
+
+	if strings.EqualFold(filepath.Ext(path), ".nupkg") {
+		identity, err := readNugetPackageIdentity(path)
+		if err != nil {
+			return err
+		}
+		source, err := filepath.Rel(programDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		source = "$(MSBuildProjectDirectory)/" + filepath.ToSlash(source)
+		contents = addFileDirective(contents, "property",
+			"RestoreAdditionalProjectSources=$(RestoreAdditionalProjectSources);"+source, nil)
+		samePackage := func(existing string) bool {
+			id, _, _ := strings.Cut(existing, "@")
+			return strings.EqualFold(strings.TrimSpace(id), identity.ID)
+		}
+		if propsFile := dotnetcodegen.CentralPackageVersionsFile(programDir); propsFile != "" {
+			if err := setCentralPackageVersion(propsFile, identity.ID, identity.Version); err != nil {
+				return err
+			}
+			contents = addFileDirective(contents, "package", identity.ID, samePackage)
+		} else {
+			contents = addFileDirective(contents, "package", identity.ID+"@"+identity.Version, samePackage)
+		}
+	} else {
+		sdkProject, err := findProjectFile(path)
+		if err != nil {
+			return err
+		}
+		refs, err := fileProgramReferences(program)
+		if err != nil {
+			return err
+		}
+		referenced := false
+		for _, ref := range refs {
+			referenced = referenced || sameFile(ref, sdkProject)
+		}
+		if !referenced {
+			rel, err := filepath.Rel(programDir, sdkProject)
+			if err != nil {
+				return err
+			}
+			contents = addFileDirective(contents, "project", filepath.ToSlash(rel), nil)
+		}
+	}
+
+	if contents == string(data) {
+		logging.V(5).Infof("%s already depends on %s", program, path)
+		return nil
+	}
+	return os.WriteFile(program, []byte(contents), info.Mode().Perm())
+}
+
+// addListProperty appends value to the `;` separated list in an MSBuild property, unless one of the property's
+// definitions already includes it.
+func addListProperty(contents, name, value string) string {
+	re := regexp.MustCompile(`<` + name + `(?:\s[^>]*)?>([^<]*)</` + name + `>`)
+	for _, m := range re.FindAllStringSubmatch(contents, -1) {
+		for _, item := range strings.Split(m[1], ";") {
+			if strings.TrimSpace(item) == value {
+				return contents
+			}
+		}
+	}
+	element := `<` + name + `>$(` + name + `);` + xmlTextEscape(value) + `</` + name + `>`
+	return dotnetcodegen.AddProjectElement(contents, "PropertyGroup", name, element)
+}
+
+// addPackageReference adds a `PackageReference` to the package, or updates the version of an existing one.  An
+// empty version leaves it to central package management.
+func addPackageReference(contents, id, version string) string {
+	re := regexp.MustCompile(`(?i)<PackageReference\s+Include\s*=\s*"` + regexp.QuoteMeta(id) + `"[^>]*>`)
+	loc := re.FindStringIndex(contents)
+	if loc == nil {
+		reference := `<PackageReference Include="` + xmlAttributeEscape(id) + `"`
+		if version != "" {
+			reference += ` Version="` + xmlAttributeEscape(version) + `"`
+		}
+		return dotnetcodegen.AddProjectElement(contents, "ItemGroup", "PackageReference", reference+" />")
+	}
+	if version == "" {
+		return contents
+	}
+	reference := contents[loc[0]:loc[1]]
+	versionRegexp := regexp.MustCompile(`(\sVersion\s*=\s*")[^"]*(")`)
+	if !versionRegexp.MatchString(reference) {
+		// Without a version here it comes from elsewhere, like Directory.Packages.props, and that's the user's to
+		// manage.
+		return contents
+	}
+	escaped := strings.ReplaceAll(xmlAttributeEscape(version), "$", "$$")
+	updated := versionRegexp.ReplaceAllString(reference, "${1}"+escaped+"${2}")
+	return contents[:loc[0]] + updated + contents[loc[1]:]
+}
+
+var (
+	xmlTextEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlAttributeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+func xmlTextEscape(s string) string {
+	return xmlTextEscaper.Replace(s)
+}
+
+func xmlAttributeEscape(s string) string {
+	return xmlAttributeEscaper.Replace(s)
+}
@@ -0,0 +1,207 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkProjectDependency(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	projectFile := filepath.Join(root, "Infra.csproj")
+	require.NoError(t, os.WriteFile(projectFile, []byte(`<Project Sdk="Microsoft.NET.Sdk">
+
+    <PropertyGroup>
+        <OutputType>Exe</OutputType>
+        <!-- The SDK is pinned by global.json. -->
+        <TargetFramework>net8.0</TargetFramework>
+    </PropertyGroup>
+
+    <ItemGroup>
+        <PackageReference Include="Pulumi" Version="3.*" />
+    </ItemGroup>
+
+</Project>
+`), 0o600))
+	sdk := filepath.Join(root, "sdks", "random")
+	require.NoError(t, os.MkdirAll(sdk, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(sdk, "Pulumi.Random.csproj"), []byte("<Project />"), 0o600))
+
+	expected := `<Project Sdk="Microsoft.NET.Sdk">
+
+    <PropertyGroup>
+        <OutputType>Exe</OutputType>
+        <!-- The SDK is pinned by global.json. -->
+        <TargetFramework>net8.0</TargetFramework>
+        <DefaultItemExcludes>$(DefaultItemExcludes);sdks/random/**</DefaultItemExcludes>
+    </PropertyGroup>
+
+    <ItemGroup>
+        <PackageReference Include="Pulumi" Version="3.*" />
+        <ProjectReference Include="sdks\random\Pulumi.Random.csproj" />
+    </ItemGroup>
+
+</Project>
+`
+	// Linking again changes nothing.
+	for i := 0; i < 2; i++ {
+		require.NoError(t, linkProjectDependency(projectFile, sdk))
+		actual, err := os.ReadFile(projectFile)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(actual))
+	}
+
+	// A prebuilt package is restored from the directory it's in.
+	packages := filepath.Join(root, "packages")
+	require.NoError(t, os.MkdirAll(packages, 0o700))
+	writeNupkg(t, filepath.Join(packages, "Pulumi.Tls.5.0.0.nupkg"), "Pulumi.Tls", "5.0.0")
+	writeNupkg(t, filepath.Join(packages, "Pulumi.Tls.5.1.0.nupkg"), "Pulumi.Tls", "5.1.0")
+	require.NoError(t, linkProjectDependency(projectFile, filepath.Join(packages, "Pulumi.Tls.5.0.0.nupkg")))
+	require.NoError(t, linkProjectDependency(projectFile, filepath.Join(packages, "Pulumi.Tls.5.1.0.nupkg")))
+	actual, err := os.ReadFile(projectFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(actual),
+		"        <DefaultItemExcludes>$(DefaultItemExcludes);sdks/random/**</DefaultItemExcludes>\n"+
+			"        <RestoreAdditionalProjectSources>"+
+			"$(RestoreAdditionalProjectSources);$(MSBuildProjectDirectory)/packages"+
+			"</RestoreAdditionalProjectSources>\n"+
+			"    </PropertyGroup>\n")
+	assert.Contains(t, string(actual), `        <PackageReference Include="Pulumi" Version="3.*" />
+        <PackageReference Include="Pulumi.Tls" Version="5.1.0" />
+        <ProjectReference Include="sdks\random\Pulumi.Random.csproj" />
+    </ItemGroup>`)
+	assert.NotContains(t, string(actual), "5.0.0")
+}
+
+func TestLinkProjectDependencyEmptyProject(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	projectFile := filepath.Join(root, "program", "Infra.csproj")
+	require.NoError(t, os.MkdirAll(filepath.Dir(projectFile), 0o700))
+	require.NoError(t, os.WriteFile(projectFile, []byte("<Project Sdk=\"Microsoft.NET.Sdk\" />\r\n"), 0o600))
+	sdk := filepath.Join(root, "sdks", "random")
+	require.NoError(t, os.MkdirAll(sdk, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(sdk, "Pulumi.Random.csproj"), []byte("<Project />"), 0o600))
+
+	// SDKs outside the project's directory aren't compiled into it, so only need referencing.
+	require.NoError(t, linkProjectDependency(projectFile, sdk))
+	actual, err := os.ReadFile(projectFile)
+	require.NoError(t, err)
+	assert.Equal(t, "<Project Sdk=\"Microsoft.NET.Sdk\">\r\n"+
+		"  <ItemGroup>\r\n"+
+		"    <ProjectReference Include=\"..\\sdks\\random\\Pulumi.Random.csproj\" />\r\n"+
+		"  </ItemGroup>\r\n"+
+		"</Project>\r\n", string(actual))
+
+	assert.ErrorContains(t, linkProjectDependency(projectFile, filepath.Join(root, "missing")), "missing")
+}
+
+func TestLinkProjectDependencyCentralPackageManagement(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	propsFile := filepath.Join(root, "Directory.Packages.props")
+	require.NoError(t, os.WriteFile(propsFile, []byte(`<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageVersion Include="Pulumi" Version="3.90.0" />
+  </ItemGroup>
+</Project>
+`), 0o600))
+	projectFile := filepath.Join(root, "Infra.csproj")
+	require.NoError(t, os.WriteFile(projectFile, []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <PackageReference Include="Pulumi" />
+  </ItemGroup>
+</Project>
+`), 0o600))
+	nupkg := filepath.Join(root, "Pulumi.Tls.5.0.0.nupkg")
+	writeNupkg(t, nupkg, "Pulumi.Tls", "5.0.0")
+
+	require.NoError(t, linkProjectDependency(projectFile, nupkg))
+	actual, err := os.ReadFile(projectFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(actual), `    <PackageReference Include="Pulumi" />
+    <PackageReference Include="Pulumi.Tls" />
+`)
+	props, err := os.ReadFile(propsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(props), `    <PackageVersion Include="Pulumi" Version="3.90.0" />
+    <PackageVersion Include="Pulumi.Tls" Version="5.0.0" />
+`)
+}
+
+func TestLinkFileProgramDependency(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	program := filepath.Join(root, "Program.cs")
+	require.NoError(t, os.WriteFile(program, []byte(`#:package Pulumi@3.*
+
+using Pulumi;
+`), 0o600))
+	sdk := filepath.Join(root, "sdks", "random")
+	require.NoError(t, os.MkdirAll(sdk, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(sdk, "Pulumi.Random.csproj"), []byte("<Project />"), 0o600))
+	packages := filepath.Join(root, "packages")
+	require.NoError(t, os.MkdirAll(packages, 0o700))
+	writeNupkg(t, filepath.Join(packages, "Pulumi.Tls.5.0.0.nupkg"), "Pulumi.Tls", "5.0.0")
+	writeNupkg(t, filepath.Join(packages, "Pulumi.Tls.5.1.0.nupkg"), "Pulumi.Tls", "5.1.0")
+
+	// Linking again changes nothing, and a newer package replaces the older one.
+	for i := 0; i < 2; i++ {
+		require.NoError(t, linkFileProgramDependency(program, sdk))
+		require.NoError(t, linkFileProgramDependency(program, filepath.Join(packages, "Pulumi.Tls.5.0.0.nupkg")))
+		require.NoError(t, linkFileProgramDependency(program, filepath.Join(packages, "Pulumi.Tls.5.1.0.nupkg")))
+	}
+	actual, err := os.ReadFile(program)
+	require.NoError(t, err)
+	assert.Equal(t, `#:package Pulumi@3.*
+#:project sdks/random/Pulumi.Random.csproj
+#:property RestoreAdditionalProjectSources=$(RestoreAdditionalProjectSources);$(MSBuildProjectDirectory)/packages
+#:package Pulumi.Tls@5.1.0
+
+using Pulumi;
+`, string(actual))
+}
+
+func writeNupkg(t *testing.T, path, id, version string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := zip.NewWriter(f)
+	nuspec, err := w.Create(id + ".nuspec")
+	require.NoError(t, err)
+	_, err = nuspec.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>` + id + `</id>
+    <version>` + version + `</version>
+  </metadata>
+</package>`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
@@ -26,6 +26,7 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -39,25 +40,23 @@ import (
 	hclsyntax "github.com/pulumi/pulumi/pkg/v3/codegen/hcl2/syntax"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/pcl"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/errutil"
-	"github.com/pulumi/pulumi/sdk/v3/go/common/util/executable"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
-	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // A exit-code we recognize when the nodejs process exits.  If we see this error, there's no
@@ -105,13 +104,25 @@ func main() {
 		engineAddress = args[0]
 	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			// Pass the signal on to any dotnet processes we're running, they're in their own process groups.
+			logging.V(3).Infof("language host received %v, stopping dotnet processes", sig)
+			stopProcessGroups(sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 	// Map the context Done channel to the rpcutil boolean cancel channel.
-	// The context will close on SIGINT or Healthcheck failure.
+	// The context will close on SIGINT, SIGTERM or Healthcheck failure.
 	cancelChannel := make(chan bool)
 	go func() {
 		<-ctx.Done()
-		cancel() // remove the interrupt handler
+		signal.Stop(signals) // remove the interrupt handler
 		close(cancelChannel)
 	}()
 	err := rpcutil.Healthcheck(ctx, engineAddress, 5*time.Minute, cancel)
@@ -137,7 +148,8 @@ func main() {
 	fmt.Printf("%d\n", handle.Port)
 
 	// And finally wait for the server to stop serving.
-	if err := <-handle.Done; err != nil {
+	err = <-handle.Done
+	if err != nil {
 		cmdutil.Exit(errors.Wrapf(err, "language host RPC stopped serving"))
 	}
 }
@@ -158,6 +170,31 @@ type dotnetOptions struct {
 	binary string
 	// Use this executable as the dotnet executor.
 	dotnetExec string
+	// The project file to build and run when the program directory contains several.
+	project string
+	// The target framework to build and run, for projects with several.
+	framework string
+	// The build configuration, such as Debug or Release.
+	configuration string
+	// The runtime identifier to build and run for, such as linux-x64.
+	runtime string
+	// Additional MSBuild properties to set on every build.
+	msbuildProperties map[string]string
+	// Package sources to restore from instead of those in NuGet.Config.
+	restoreSources []string
+	// Publish the program ahead of running it, one of readytorun, selfcontained or aot.
+	publish string
+	// Whether builds use long-lived MSBuild nodes and compiler servers.  When true they keep running after the language
+	// host stops, for the next build to reuse, when false none are started, and when unset the SDK's defaults apply.
+	buildServer *bool
+	// Restore in locked mode even without a packages.lock.json next to the project.
+	lockedMode bool
+	// The directory to write MSBuild binary logs of every build to, if any.
+	binlog string
+	// How to attach a debugger to the program.
+	debugger debuggerOptions
+	// Rebuild and restart plugins run from source whenever their sources change.
+	watch bool
 }
 
 func parseOptions(root string, options map[string]interface{}) (dotnetOptions, error) {
@@ -178,6 +215,97 @@ func parseOptions(root string, options map[string]interface{}) (dotnetOptions, e
 		}
 	}
 
+	var err error
+	if dotnetOptions.project, err = stringOption(options, "project"); err != nil {
+		return dotnetOptions, err
+	}
+	if dotnetOptions.framework, err = stringOption(options, "framework"); err != nil {
+		return dotnetOptions, err
+	}
+	if dotnetOptions.configuration, err = stringOption(options, "configuration"); err != nil {
+		return dotnetOptions, err
+	}
+	if dotnetOptions.runtime, err = stringOption(options, "runtime"); err != nil {
+		return dotnetOptions, err
+	}
+	if dotnetOptions.publish, err = stringOption(options, "publish"); err != nil {
+		return dotnetOptions, err
+	}
+	switch dotnetOptions.publish {
+	case "", publishReadyToRun, publishSelfContained, publishAOT:
+	default:
+		return dotnetOptions, errors.Errorf("publish option must be one of %s, %s or %s, not %q",
+			publishReadyToRun, publishSelfContained, publishAOT, dotnetOptions.publish)
+	}
+
+	if buildServer, ok := options["buildServer"]; ok {
+		if buildServer, ok := buildServer.(bool); ok {
+			dotnetOptions.buildServer = &buildServer
+		} else {
+			return dotnetOptions, errors.New("buildServer option must be a bool")
+		}
+	}
+
+	if lockedMode, ok := options["lockedMode"]; ok {
+		if lockedMode, ok := lockedMode.(bool); ok {
+			dotnetOptions.lockedMode = lockedMode
+		} else {
+			return dotnetOptions, errors.New("lockedMode option must be a bool")
+		}
+	}
+
+	if watch, ok := options["watch"]; ok {
+		if watch, ok := watch.(bool); ok {
+			dotnetOptions.watch = watch
+		} else {
+			return dotnetOptions, errors.New("watch option must be a bool")
+		}
+	}
+
+	if dotnetOptions.binlog, err = parseBinlogOption(root, options); err != nil {
+		return dotnetOptions, err
+	}
+
+	if debugger, ok := options["debugger"]; ok {
+		if dotnetOptions.debugger, err = parseDebuggerOptions(root, debugger); err != nil {
+			return dotnetOptions, err
+		}
+	}
+
+	if properties, ok := options["msbuildProperties"]; ok {
+		properties, ok := properties.(map[string]interface{})
+		if !ok {
+			return dotnetOptions, errors.New("msbuildProperties option must be a map of property names to values")
+		}
+		dotnetOptions.msbuildProperties = make(map[string]string, len(properties))
+		for name, value := range properties {
+			switch value := value.(type) {
+			case string:
+				dotnetOptions.msbuildProperties[name] = value
+			case bool:
+				dotnetOptions.msbuildProperties[name] = strconv.FormatBool(value)
+			case float64:
+				dotnetOptions.msbuildProperties[name] = strconv.FormatFloat(value, 'f', -1, 64)
+			default:
+				return dotnetOptions, errors.Errorf("msbuildProperties option %s must be a string, number or bool", name)
+			}
+		}
+	}
+
+	if sources, ok := options["restoreSources"]; ok {
+		sources, ok := sources.([]interface{})
+		if !ok {
+			return dotnetOptions, errors.New("restoreSources option must be a list of strings")
+		}
+		for _, source := range sources {
+			source, ok := source.(string)
+			if !ok {
+				return dotnetOptions, errors.New("restoreSources option must be a list of strings")
+			}
+			dotnetOptions.restoreSources = append(dotnetOptions.restoreSources, source)
+		}
+	}
+
 	switch {
 	case dotnetOptions.dotnetExec != "":
 		logging.V(3).Infof("language host asked to use specific executor: `%s`", dotnetOptions.dotnetExec)
@@ -197,6 +325,72 @@ func parseOptions(root string, options map[string]interface{}) (dotnetOptions, e
 	return dotnetOptions, nil
 }
 
+// stringOption returns the value of an optional string runtime option.
+func stringOption(options map[string]interface{}, key string) (string, error) {
+	value, ok := options[key]
+	if !ok {
+		return "", nil
+	}
+	if value, ok := value.(string); ok {
+		return value, nil
+	}
+	return "", errors.Errorf("%s option must be a string", key)
+}
+
+// projectPath returns the project file to build and run for the given program, see resolveProject.  An explicit
+// entry point takes precedence over the `project` option.
+func (opts dotnetOptions) projectPath(programDirectory, entryPoint string) (string, error) {
+	if (entryPoint == "" || entryPoint == ".") && opts.project != "" {
+		entryPoint = opts.project
+	}
+	return resolveProject(programDirectory, entryPoint)
+}
+
+// msbuildArgs returns the arguments that select what and how to build.  Every `dotnet build`, `dotnet run` and
+// `dotnet restore` the host runs for the program uses them, so that every command sees the same build.
+func (opts dotnetOptions) msbuildArgs() []string {
+	var args []string
+	if opts.configuration != "" {
+		args = append(args, "--configuration", opts.configuration)
+	}
+	if opts.framework != "" {
+		args = append(args, "--framework", opts.framework)
+	}
+	if opts.runtime != "" {
+		args = append(args, "--runtime", opts.runtime)
+	}
+	return append(args, opts.propertyArgs()...)
+}
+
+// propertyArgs returns the `--property` arguments for the MSBuild properties set by the options, sorted by name.
+func (opts dotnetOptions) propertyArgs() []string {
+	var args []string
+	properties := make(map[string]string, len(opts.msbuildProperties)+2)
+	if opts.buildServer != nil && !*opts.buildServer {
+		properties["UseSharedCompilation"] = "false"
+	}
+	for name, value := range opts.msbuildProperties {
+		properties[name] = value
+	}
+	if len(opts.restoreSources) > 0 {
+		// `dotnet run` has no `--source` flag, but all the commands accept properties.
+		properties["RestoreSources"] = strings.Join(opts.restoreSources, ";")
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "--property:"+name+"="+msbuildPropertyEscaper.Replace(properties[name]))
+	}
+	return args
+}
+
+// msbuildPropertyEscaper escapes the characters MSBuild would otherwise take to separate properties on the command
+// line.
+var msbuildPropertyEscaper = strings.NewReplacer("%", "%25", ";", "%3B", ",", "%2C")
+
 func newLanguageHost(engineAddress, tracing, otelEndpoint string) pulumirpc.LanguageRuntimeServer {
 	return &dotnetLanguageHost{
 		engineAddress: engineAddress,
@@ -243,59 +437,125 @@ func (host *dotnetLanguageHost) GetRequiredPackages(ctx context.Context,
 		return &pulumirpc.GetRequiredPackagesResponse{}, nil
 	}
 
-	engineClient, closer, err := host.connectToEngine()
+	// After a restore NuGet has already recorded everything we need to know, so try to avoid spawning any processes.
+	// If the assets file can't be trusted we fall back to asking `dotnet` directly.
+	var possiblePulumiPackages []packageReference
+	var packageDirs []string
+	project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
 	if err != nil {
 		return nil, err
 	}
-	defer contract.IgnoreClose(closer)
-
-	// First do a `dotnet build`.  This will ensure that all the nuget dependencies of the project
-	// are restored and locally available for us.
-	if err := host.DotnetBuild(ctx, opts.dotnetExec, req, engineClient); err != nil {
-		return nil, err
+	if assets, projectFile, assetsErr := readProjectAssets(project); assetsErr == nil {
+		logging.V(5).Infof("GetRequiredPackages: using restored assets of %s", projectFile)
+		possiblePulumiPackages, err = pulumiPackageCandidates(assets.packageList(projectFile), project)
+		if err != nil {
+			return nil, err
+		}
+		packageDirs = assets.packageFolders()
+	} else {
+		logging.V(5).Infof("GetRequiredPackages: cannot use restored assets, running dotnet: %v", assetsErr)
+		possiblePulumiPackages, packageDirs, err = host.determinePackagesWithDotnet(ctx, opts, req)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// now, introspect the user project to see which pulumi resource packages it references.
-	possiblePulumiPackages, err := host.DeterminePossiblePulumiPackages(
-		ctx, opts.dotnetExec, engineClient, req.Info.ProgramDirectory, req.Info.EntryPoint)
-	if err != nil {
-		return nil, err
+	// Now that we know the set of pulumi packages referenced and we know where packages have been restored to,
+	// we can examine each package to determine the corresponding resource-plugin for it.
+
+	packages := []*pulumirpc.PackageDependency{}
+	seen := map[string]bool{}
+	for _, pkg := range possiblePulumiPackages {
+		plugins, err := DeterminePackageDependencies(packageDirs, pkg.ID, pkg.ResolvedVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		packages = appendPackageDependencies(packages, seen, plugins)
 	}
 
-	// Ensure we know where the local nuget package cache directory is.  User can specify where that
-	// is located, so this makes sure we respect any custom location they may have.
-	packageDir, err := host.DetermineDotnetPackageDirectory(ctx, opts.dotnetExec, engineClient, req.Info.ProgramDirectory)
+	// Locally generated SDKs are referenced as projects rather than packages.
+	projectPlugins, err := projectReferenceDependencies(project)
 	if err != nil {
 		return nil, err
 	}
+	packages = appendPackageDependencies(packages, seen, projectPlugins)
 
-	// Now that we know the set of pulumi packages referenced and we know where packages have been restored to,
-	// we can examine each package to determine the corresponding resource-plugin for it.
-
-	packages := []*pulumirpc.PackageDependency{}
-	packageToVersion := make(map[string]string)
-	for _, parts := range possiblePulumiPackages {
-		packageName := parts[0]
-		packageVersion := parts[1]
+	return &pulumirpc.GetRequiredPackagesResponse{Packages: packages}, nil
+}
 
-		if existingVersion := packageToVersion[packageName]; existingVersion == packageVersion {
-			// only include distinct dependencies.
+// appendPackageDependencies appends the plugins that aren't in seen yet, several packages can need the same analyzer
+// or tool.
+func appendPackageDependencies(
+	packages []*pulumirpc.PackageDependency, seen map[string]bool, plugins []*pulumirpc.PackageDependency,
+) []*pulumirpc.PackageDependency {
+	for _, plugin := range plugins {
+		key := plugin.Kind + "/" + plugin.Name + "@" + plugin.Version
+		// Parameterized packages share their base plugin, but are each needed.
+		if p := plugin.Parameterization; p != nil {
+			key += "/" + p.Name + "@" + p.Version
+		} else if p := plugin.Extension; p != nil {
+			key += "/extension/" + p.Name + "@" + p.Version
+		}
+		if seen[key] {
 			continue
 		}
+		seen[key] = true
+		packages = append(packages, plugin)
+	}
+	return packages
+}
+
+// determinePackagesWithDotnet builds the program and asks `dotnet` which packages it references, and determines
+// where they were restored to.
+func (host *dotnetLanguageHost) determinePackagesWithDotnet(
+	ctx context.Context, opts dotnetOptions, req *pulumirpc.GetRequiredPackagesRequest,
+) ([]packageReference, []string, error) {
+	engineClient, closer, err := host.connectToEngine()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer contract.IgnoreClose(closer)
 
-		packageToVersion[packageName] = packageVersion
+	// First do a `dotnet build`.  This will ensure that all the nuget dependencies of the project
+	// are restored and locally available for us.
+	if err := host.DotnetBuild(ctx, opts, req, engineClient); err != nil {
+		return nil, nil, err
+	}
 
-		plugin, err := DeterminePackageDependency(packageDir, packageName, packageVersion)
+	// Ensure we know where the local nuget package cache directory is.  User can specify where that
+	// is located, so this makes sure we respect any custom location they may have.
+	project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	packageDirs, err := resolvePackageFolders(project)
+	if err != nil {
+		return nil, nil, err
+	}
+	logging.V(5).Infof("GetRequiredPackages: Package directories: %v", packageDirs)
+
+	// now, introspect the user project to see which pulumi resource packages it references.
+	var possiblePulumiPackages []packageReference
+	if isFileBasedProgram(project) {
+		// `dotnet list package` doesn't take file-based programs, but their packages are listed in the file.
+		list, err := fileProgramPackages(project, packageDirs)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-
-		if plugin != nil {
-			packages = append(packages, plugin)
+		possiblePulumiPackages, err = pulumiPackageCandidates(list, project)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		possiblePulumiPackages, err = host.DeterminePossiblePulumiPackages(
+			ctx, opts.dotnetExec, engineClient, req.Info.ProgramDirectory, project)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
-	return &pulumirpc.GetRequiredPackagesResponse{Packages: packages}, nil
+	return possiblePulumiPackages, packageDirs, nil
 }
 
 func (host *dotnetLanguageHost) DeterminePossiblePulumiPackages(
@@ -303,65 +563,50 @@ func (host *dotnetLanguageHost) DeterminePossiblePulumiPackages(
 	dotnetExec string,
 	engineClient pulumirpc.EngineClient,
 	programDirectory string,
-	entryPoint string,
-) ([][]string, error) {
+	project string,
+) ([]packageReference, error) {
 	logging.V(5).Infof("GetRequiredPlugins: Determining pulumi packages")
 
 	// Run the `dotnet list package --include-transitive` command.  Importantly, do not clutter the
 	// stream with the extra steps we're performing. This is just so we can determine the required
 	// plugins.  And, after the first time we do this, subsequent runs will see that the plugin is
 	// installed locally and not need to do anything.
-	project := resolveProjectPath(programDirectory, entryPoint)
-	args := []string{"list", project, "package", "--include-transitive"}
-	commandStr := strings.Join(args, " ")
-	commandOutput, err := runDiscoveryCommand(ctx, dotnetExec, engineClient, args, programDirectory)
+	list, err := listPackages(ctx, dotnetExec, engineClient, project, programDirectory, true /*transitive*/)
 	if err != nil {
 		return nil, err
 	}
-	logging.V(5).Infof("GetRequiredPlugins: dotnet list package --include-transitive: %q", commandOutput)
 
-	// expected output should be like so:
-	//
-	//    Project 'Aliases' has the following package references
-	//    [netcoreapp3.1]:
-	//    Top-level Package      Requested                        Resolved
-	//    > Pulumi               1.5.0-preview-alpha.1572911568   1.5.0-preview-alpha.1572911568
-	//
-	//    Transitive Package                                       Resolved
-	//    > Google.Protobuf                                        3.10.0
-	//    > Grpc                                                   2.24.0
-	outputLines := strings.Split(strings.ReplaceAll(commandOutput, "\r\n", "\n"), "\n")
+	return pulumiPackageCandidates(list, project)
+}
 
+// pulumiPackageCandidates returns every distinct package referenced by any project and target framework in the list
+// that could carry a plugin, which is any package except the core SDK itself.
+func pulumiPackageCandidates(list *packageList, project string) ([]packageReference, error) {
 	sawPulumi := false
-	packages := [][]string{}
-	for _, line := range outputLines {
-		fields := strings.Fields(line)
-		if len(fields) < 3 {
-			continue
-		}
-
-		// Has to start with `>` and have at least 3 chunks:
-		//
-		//    > name requested_ver? resolved_ver
-		if fields[0] != ">" {
-			continue
-		}
+	seen := map[string]bool{}
+	packages := []packageReference{}
+	for _, p := range list.Projects {
+		for _, f := range p.Frameworks {
+			logging.V(5).Infof("GetRequiredPlugins: %s [%s]: %d packages", p.Path, f.Framework, len(f.Packages))
+			for _, pkg := range f.Packages {
+				if pkg.ID == "Pulumi" {
+					sawPulumi = true
+					continue
+				}
 
-		// We only care about `Pulumi.` packages
-		packageName := fields[1]
-		if packageName == "Pulumi" {
-			sawPulumi = true
-			continue
+				key := strings.ToLower(pkg.ID) + "@" + pkg.ResolvedVersion
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				packages = append(packages, pkg)
+			}
 		}
-
-		version := fields[len(fields)-1]
-		packages = append(packages, []string{packageName, version})
 	}
 
 	if !sawPulumi && len(packages) == 0 {
 		return nil, errors.Errorf(
-			"unexpected output from 'dotnet %v'. Program does not appear to reference any 'Pulumi.*' packages",
-			commandStr)
+			"unexpected packages for %v. Program does not appear to reference any 'Pulumi.*' packages", project)
 	}
 
 	logging.V(5).Infof("GetRequiredPlugins: Pulumi packages: %#v", packages)
@@ -369,38 +614,6 @@ func (host *dotnetLanguageHost) DeterminePossiblePulumiPackages(
 	return packages, nil
 }
 
-func (host *dotnetLanguageHost) DetermineDotnetPackageDirectory(
-	ctx context.Context,
-	dotnetExec string,
-	engineClient pulumirpc.EngineClient,
-	programDirectory string,
-) (string, error) {
-	logging.V(5).Infof("GetRequiredPackages: Determining package directory")
-
-	// Run the `dotnet nuget locals global-packages --list` command.  Importantly, do not clutter
-	// the stream with the extra steps we're performing. This is just so we can determine the
-	// required plugins.  And, after the first time we do this, subsequent runs will see that the
-	// plugin is installed locally and not need to do anything.
-	args := []string{"nuget", "locals", "global-packages", "--list"}
-	commandStr := strings.Join(args, " ")
-	commandOutput, err := runDiscoveryCommand(ctx, dotnetExec, engineClient, args, programDirectory)
-	if err != nil {
-		return "", err
-	}
-
-	// expected output should be like so: "info : global-packages: /home/cyrusn/.nuget/packages/"
-	// so grab the portion after "global-packages:"
-	index := strings.Index(commandOutput, "global-packages:")
-	if index < 0 {
-		return "", errors.Errorf("Unexpected output from 'dotnet %v': %v", commandStr, commandOutput)
-	}
-
-	dir := strings.TrimSpace(commandOutput[index+len("global-packages:"):])
-	logging.V(5).Infof("GetRequiredPlugins: Package directory: %v", dir)
-
-	return dir, nil
-}
-
 type versionFile struct {
 	name    string
 	version string
@@ -427,25 +640,133 @@ func newVersionFile(b []byte, packageName string) *versionFile {
 	}
 }
 
-func DeterminePackageDependency(packageDir, packageName, packageVersion string) (*pulumirpc.PackageDependency, error) {
+// pulumiPluginJSON extends the `pulumi-plugin.json` of a package with the kind of its own plugin, and with other
+// plugins it depends on:
+//
+//	{
+//	  "kind": "analyzer",
+//	  "name": "aws-guard",
+//	  "plugins": [{"kind": "converter", "name": "terraform", "version": "1.0.0"}]
+//	}
+type pulumiPluginJSON struct {
+	plugin.PulumiPluginJSON
+	// The kind of the package's own plugin, setting this implies the package has one.  Defaults to resource.
+	Kind string `json:"kind,omitempty"`
+	// Other plugins the package needs.
+	Plugins []pulumiPluginDependencyJSON `json:"plugins,omitempty"`
+}
+
+type pulumiPluginDependencyJSON struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Server  string `json:"server,omitempty"`
+}
+
+func loadPulumiPluginJSON(path string) (*pulumiPluginJSON, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var result pulumiPluginJSON
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, err
+	}
+	if result.Kind != "" && !apitype.IsPluginKind(result.Kind) {
+		return nil, fmt.Errorf("%s: invalid plugin kind %q", path, result.Kind)
+	}
+	for _, p := range result.Plugins {
+		if p.Name == "" {
+			return nil, fmt.Errorf("%s: plugins must have a name", path)
+		}
+		if !apitype.IsPluginKind(p.Kind) {
+			return nil, fmt.Errorf("%s: invalid kind %q for plugin %s", path, p.Kind, p.Name)
+		}
+		if p.Version != "" {
+			if _, err := semver.ParseTolerant(p.Version); err != nil {
+				return nil, fmt.Errorf("%s: invalid version for plugin %s: %w", path, p.Name, err)
+			}
+		}
+	}
+	return &result, nil
+}
+
+// DeterminePackageDependencies returns the plugin of a package, if it has one, followed by any other plugins it
+// declares it needs.
+func DeterminePackageDependencies(
+	packageDirs []string, packageName, packageVersion string,
+) ([]*pulumirpc.PackageDependency, error) {
+	result, plugins, err := determinePackageDependencies(packageDirs, packageName, packageVersion)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		plugins = append([]*pulumirpc.PackageDependency{result}, plugins...)
+	}
+	return plugins, nil
+}
+
+// determinePackageDependencies returns the plugin of a package, if it has one, and any other plugins it declares it
+// needs.
+func determinePackageDependencies(
+	packageDirs []string, packageName, packageVersion string,
+) (*pulumirpc.PackageDependency, []*pulumirpc.PackageDependency, error) {
 	logging.V(5).Infof("GetRequiredPlugins: Determining plugin dependency: %v, %v, %v",
-		packageDir, packageName, packageVersion)
+		packageDirs, packageName, packageVersion)
 
 	// Check for a `~/.nuget/packages/package_name/package_version/content/{pulumi-plugin.json,version.txt}` file.
+	// The package is in the first of the global packages folder and any fallback folders that has it.
+	packagePath := ""
+	for _, packageDir := range packageDirs {
+		for _, version := range []string{packageVersion, strings.ToLower(packageVersion)} {
+			path := filepath.Join(packageDir, strings.ToLower(packageName), version)
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				packagePath = path
+				break
+			}
+		}
+		if packagePath != "" {
+			break
+		}
+	}
+	if packagePath == "" {
+		logging.V(5).Infof("GetRequiredPlugins: package %v %v not found in %v", packageName, packageVersion, packageDirs)
+		return nil, nil, nil
+	}
 
-	artifactPath := filepath.Join(packageDir, strings.ToLower(packageName), packageVersion, "content")
+	return pluginDependencies(filepath.Join(packagePath, "content"), packageName, packageVersion)
+}
+
+// pluginDependencies reads the `pulumi-plugin.json` and `version.txt` files in artifactPath, which is either the
+// content folder of a restored package or the directory of a referenced project, and returns the plugin they
+// describe, if any, and any other plugins they need.
+func pluginDependencies(
+	artifactPath, packageName, packageVersion string,
+) (*pulumirpc.PackageDependency, []*pulumirpc.PackageDependency, error) {
 	pulumiPluginFilePath := filepath.Join(artifactPath, "pulumi-plugin.json")
 	versionFilePath := filepath.Join(artifactPath, "version.txt")
 	logging.V(5).Infof("GetRequiredPlugins: plugin file path: %v", versionFilePath)
 	logging.V(5).Infof("GetRequiredPlugins: version file path: %v", versionFilePath)
 
-	pulumiPlugin, err := plugin.LoadPulumiPluginJSON(pulumiPluginFilePath)
+	pulumiPlugin, err := loadPulumiPluginJSON(pulumiPluginFilePath)
 	if err != nil && !os.IsNotExist(err) {
-		return nil, err
+		return nil, nil, err
 	}
-	// Explicitly not a resource
-	if pulumiPlugin != nil && !pulumiPlugin.Resource {
-		return nil, nil
+
+	var plugins []*pulumirpc.PackageDependency
+	if pulumiPlugin != nil {
+		for _, p := range pulumiPlugin.Plugins {
+			plugins = append(plugins, &pulumirpc.PackageDependency{
+				Kind:    p.Kind,
+				Name:    p.Name,
+				Version: p.Version,
+				Server:  p.Server,
+			})
+		}
+		// Explicitly no plugin of its own
+		if !pulumiPlugin.Resource && pulumiPlugin.Kind == "" {
+			return nil, plugins, nil
+		}
 	}
 
 	var vf *versionFile
@@ -457,7 +778,7 @@ func DeterminePackageDependency(packageDir, packageName, packageVersion string)
 	case os.IsNotExist(err):
 		break
 	default:
-		return nil, fmt.Errorf("failed to read version file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read version file: %w", err)
 	}
 
 	defaultName := strings.ToLower(strings.TrimPrefix(packageName, "Pulumi."))
@@ -465,11 +786,11 @@ func DeterminePackageDependency(packageDir, packageName, packageVersion string)
 	// No pulumi-plugin.json or version.txt
 	// That means this is not a resource.
 	if pulumiPlugin == nil && vf == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 	// Create stubs to avoid dereferencing a null
 	if pulumiPlugin == nil {
-		pulumiPlugin = &plugin.PulumiPluginJSON{}
+		pulumiPlugin = &pulumiPluginJSON{}
 	} else if vf == nil {
 		vf = &versionFile{}
 	}
@@ -487,14 +808,14 @@ func DeterminePackageDependency(packageDir, packageName, packageVersion string)
 	version := or(pulumiPlugin.Version, vf.version, packageVersion)
 	_, err = semver.ParseTolerant(version)
 	if err != nil {
-		return nil, fmt.Errorf("invalid package version: %w", err)
+		return nil, nil, fmt.Errorf("invalid package version: %w", err)
 	}
 
 	result := &pulumirpc.PackageDependency{
 		Name:    name,
 		Version: version,
 		Server:  pulumiPlugin.Server,
-		Kind:    "resource",
+		Kind:    or(pulumiPlugin.Kind, string(apitype.ResourcePlugin)),
 	}
 
 	if pulumiPlugin.Parameterization != nil {
@@ -512,27 +833,89 @@ func DeterminePackageDependency(packageDir, packageName, packageVersion string)
 	}
 
 	logging.V(5).Infof("GetRequiredPackages: Determining plugin dependency: %#v", result)
-	return result, nil
+	return result, plugins, nil
 }
 
 func (host *dotnetLanguageHost) DotnetBuild(
-	ctx context.Context, dotnetExec string, req *pulumirpc.GetRequiredPackagesRequest, engineClient pulumirpc.EngineClient,
+	ctx context.Context, opts dotnetOptions, req *pulumirpc.GetRequiredPackagesRequest,
+	engineClient pulumirpc.EngineClient,
+) error {
+	project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
+	if err != nil {
+		return err
+	}
+	ctx = opts.withEngineBinlogs(ctx, "GetRequiredPackages", engineClient)
+	return host.buildProject(ctx, opts, engineClient, project, req.Info.ProgramDirectory)
+}
+
+// buildProject runs `dotnet build` on project, unless an earlier build, possibly by another language host, saw
+// exactly the same inputs.
+func (host *dotnetLanguageHost) buildProject(
+	ctx context.Context, opts dotnetOptions, engineClient pulumirpc.EngineClient, project, programDirectory string,
 ) error {
-	project := resolveProjectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
-	args := []string{"build", "-nologo", project}
+	args := opts.buildArgs(project)
+	cache, err := newBuildCache(ctx, opts.dotnetExec, project, args)
+	if err != nil {
+		logging.V(5).Infof("not caching the build of %s: %v", project, err)
+	} else if cache.upToDate() {
+		logging.V(5).Infof("skipping 'dotnet build' of %s: nothing changed since the last build", project)
+		host.dotnetBuildSucceeded = true
+		return nil
+	}
 
 	// Run the `dotnet build` command.  Importantly, report the output of this to the user
 	// (ephemerally) as it is happening so they're aware of what's going on and can see the progress
 	// of things.
-	_, err := RunDotnetCommand(ctx, dotnetExec, engineClient, args, true /*logToUser*/, req.Info.ProgramDirectory)
+	output, err := RunDotnetCommand(ctx, opts.dotnetExec, engineClient, args, true /*logToUser*/, programDirectory)
 	if err != nil {
 		return err
 	}
 
+	if cache != nil {
+		if err := cache.record(output); err != nil {
+			logging.V(5).Infof("could not record the build of %s: %v", project, err)
+		}
+	}
 	host.dotnetBuildSucceeded = true
 	return nil
 }
 
+// buildArgs returns the arguments used to build a project.  `dotnet run` builds the same way, so a project built
+// with these arguments can be run with `--no-build`.
+func (opts dotnetOptions) buildArgs(project string) []string {
+	args := append([]string{"build", "-nologo", project}, opts.msbuildArgs()...)
+	return append(args, opts.nodeReuseArgs()...)
+}
+
+// runArgs returns the arguments to `dotnet run` a project, or a file-based program, from source.
+func (opts dotnetOptions) runArgs(project string, noBuild bool) []string {
+	args := []string{"run"}
+	if noBuild {
+		args = append(args, "--no-build")
+	}
+	if isFileBasedProgram(project) {
+		// A file-based program is the first argument, `--project` only takes project files.
+		args = append(args, project)
+	} else {
+		args = append(args, "--project", project)
+	}
+	return append(args, opts.msbuildArgs()...)
+}
+
+// projectBuildUpToDate returns true if project was built, by this or an earlier language host, with inputs that
+// haven't changed since.
+func (host *dotnetLanguageHost) projectBuildUpToDate(ctx context.Context, opts dotnetOptions, project string) bool {
+	if host.dotnetBuildSucceeded {
+		return true
+	}
+	cache, err := newBuildCache(ctx, opts.dotnetExec, project, opts.buildArgs(project))
+	if err != nil {
+		logging.V(5).Infof("could not check the build cache of %s: %v", project, err)
+		return false
+	}
+	return cache.upToDate()
+}
+
 // runDiscoveryCommand runs a read-only, idempotent dotnet command used for package
 // discovery, retrying on failure. These commands are occasionally killed on
 // resource-starved machines (for example, OOM-killed on CI runners), and since they
@@ -544,10 +927,17 @@ func runDiscoveryCommand(
 	args []string,
 	programDirectory string,
 ) (string, error) {
+	ctx, span := cmdutil.StartSpan(ctx, otel.Tracer(tracerName), "runDiscoveryCommand",
+		trace.WithAttributes(attribute.StringSlice("args", args)))
+	defer span.End()
+
 	const tries = 3
 	for attempt := 1; ; attempt++ {
-		output, err := RunDotnetCommand(ctx, dotnetExec, engineClient, args, false /*logToUser*/, programDirectory)
+		// Only the errors of the last attempt are the user's to see, earlier ones may well be fixed by retrying.
+		output, err := runDotnetCommand(withAttempt(ctx, attempt), dotnetExec, engineClient, args,
+			false /*logToUser*/, attempt == tries /*logDiagnostics*/, programDirectory)
 		if err == nil || attempt == tries || ctx.Err() != nil {
+			span.SetAttributes(attribute.Int("pulumi.dotnet.attempts", attempt))
 			return output, err
 		}
 		logging.V(5).Infof("'dotnet %v' failed (attempt %d of %d), retrying: %v",
@@ -562,6 +952,18 @@ func RunDotnetCommand(
 	engineClient pulumirpc.EngineClient,
 	args []string, logToUser bool,
 	programDirectory string,
+) (string, error) {
+	return runDotnetCommand(ctx, dotnetExec, engineClient, args, logToUser, true /*logDiagnostics*/, programDirectory)
+}
+
+// runDotnetCommand runs dotnet with args, sending any MSBuild errors and warnings of a failed run to the engine when
+// logDiagnostics is true.
+func runDotnetCommand(
+	ctx context.Context,
+	dotnetExec string,
+	engineClient pulumirpc.EngineClient,
+	args []string, logToUser, logDiagnostics bool,
+	programDirectory string,
 ) (string, error) {
 	commandStr := strings.Join(args, " ")
 	if logging.V(5).Enabled() {
@@ -595,7 +997,8 @@ func RunDotnetCommand(
 	}
 
 	// Now simply spawn a process to execute the requested program, wiring up stdout/stderr directly.
-	cmd := exec.CommandContext(ctx, dotnetExec, args...) //nolint:gas // intentionally running dynamic program name.
+	cmd, release := dotnetCommand(ctx, dotnetExec, args...)
+	defer release()
 	cmd.Stdout = infoWriter
 	cmd.Stderr = errorWriter
 	cmd.Dir = programDirectory
@@ -605,14 +1008,26 @@ func RunDotnetCommand(
 	}
 
 	if err := cmd.Run(); err != nil {
-		// The command failed.  Dump any data we collected to the actual stdout/stderr streams so
-		// they get displayed to the user.
+		// The command failed.  If it reported errors in MSBuild's format, send those to the engine so the user
+		// sees them first, rather than having to find them among the restore and build noise.
+		diagnostics := parseMSBuildDiagnostics(infoBuffer.String() + "\n" + errorBuffer.String())
+		summary := summarizeMSBuildErrors(diagnostics)
+		if summary != "" && logDiagnostics && engineClient != nil {
+			if logErr := logMSBuildDiagnostics(ctx, engineClient, diagnostics); logErr != nil {
+				logging.V(5).Infof("could not send build diagnostics to the engine: %v", logErr)
+			}
+		}
+		// Then dump any data we collected to the actual stdout/stderr streams so they get displayed to the
+		// user, for the restore and SDK errors that aren't in MSBuild's format.
 		os.Stdout.Write(infoBuffer.Bytes())
 		os.Stderr.Write(errorBuffer.Bytes())
 
 		if exiterr, ok := err.(*exec.ExitError); ok {
 			// If the program ran, but exited with a non-zero error code.  This will happen often, since user
 			// errors will trigger this.  So, the error message should look as nice as possible.
+			if summary != "" {
+				return "", errors.Errorf("'dotnet %v' failed:\n%s", commandStr, summary)
+			}
 			if status, stok := exiterr.Sys().(syscall.WaitStatus); stok {
 				return "", errors.Errorf(
 					"'dotnet %v' exited with non-zero exit code: %d", commandStr, status.ExitStatus())
@@ -630,14 +1045,6 @@ func RunDotnetCommand(
 	return infoBuffer.String(), err
 }
 
-func resolveProjectPath(programDirectory string, entryPoint string) string {
-	if entryPoint == "" || entryPoint == "." {
-		return programDirectory
-	}
-
-	return filepath.Join(programDirectory, entryPoint)
-}
-
 type logWriter struct {
 	ctx          context.Context
 	logToUser    bool
@@ -673,73 +1080,31 @@ func (w *logWriter) LogToUser(val string) (int, error) {
 	return len(val), nil
 }
 
-// When debugging, we need to build the project, as the debugger does not support running using `dotnet run`.
-// This function will build the project and return the path to the built DLL.
-func buildDebuggingDLL(ctx context.Context, dotnetExec, programDirectory, entryPoint string) (string, error) {
-	// If we are running from source, we need to build the project.
-	// Run the `dotnet build` command.  Importantly, report the output of this to the user
-	// (ephemerally) as it is happening so they're aware of what's going on and can see the progress
-	// of things.
-	cmd := exec.CommandContext(ctx, dotnetExec, //nolint:gosec // Allow sub-process to accept remote inputs
-		"build", "-nologo", "-o",
-		filepath.Join(programDirectory, "bin", "pulumi-debugging"),
-		filepath.Join(programDirectory, entryPoint),
-	)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", errors.Wrapf(err, "failed to build project: %v, output: %v", err, string(out))
-	}
-
-	if entryPoint != "." {
-		lastDot := strings.LastIndex(entryPoint, ".")
-		if lastDot == -1 {
-			return "", errors.New("entry point must have a file extension")
-		}
-		return filepath.Join("bin", "pulumi-debugging", entryPoint[:lastDot]+".dll"), nil
-	}
-
-	var binaryPath string
-	err = filepath.WalkDir(programDirectory, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if name, ok := strings.CutSuffix(d.Name(), ".csproj"); ok {
-			binaryPath = filepath.Join("bin", "pulumi-debugging", name+".dll")
-			return filepath.SkipAll
-		}
-		if name, ok := strings.CutSuffix(d.Name(), ".fsproj"); ok {
-			binaryPath = filepath.Join("bin", "pulumi-debugging", name+".dll")
-			return filepath.SkipAll
-		}
-		if name, ok := strings.CutSuffix(d.Name(), ".vbproj"); ok {
-			binaryPath = filepath.Join("bin", "pulumi-debugging", name+".dll")
-			return filepath.SkipAll
-		}
-		return nil
-	})
-
-	return binaryPath, err
-}
-
 // Run is the RPC endpoint for LanguageRuntimeServer::Run
 func (host *dotnetLanguageHost) Run(ctx context.Context, req *pulumirpc.RunRequest) (*pulumirpc.RunResponse, error) {
 	opts, err := parseOptions(req.Info.RootDirectory, req.Info.Options.AsMap())
 	if err != nil {
 		return nil, err
 	}
+	ctx = opts.withBinlogs(ctx, "Run", os.Stderr)
 
 	binaryPath := opts.binary
 
 	if req.GetAttachDebugger() && opts.binary == "" {
 		var err error
 		binaryPath, err = buildDebuggingDLL(ctx,
-			opts.dotnetExec, req.GetInfo().GetProgramDirectory(), req.GetInfo().GetEntryPoint())
+			opts, req.GetInfo().GetProgramDirectory(), req.GetInfo().GetEntryPoint())
+		if err != nil {
+			return nil, err
+		}
+	} else if opts.binary == "" && opts.publish != "" {
+		project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
 		if err != nil {
 			return nil, err
 		}
-		if binaryPath == "" {
-			return nil, errors.New("failed to find project file, and could not start debugging")
+		binaryPath, err = publishProgram(ctx, opts, project, req.Info.ProgramDirectory, os.Stdout, os.Stderr)
+		if err != nil {
+			return nil, err
 		}
 	}
 	config, err := host.constructConfig(req)
@@ -755,27 +1120,53 @@ func (host *dotnetLanguageHost) Run(ctx context.Context, req *pulumirpc.RunReque
 
 	executable := opts.dotnetExec
 	args := []string{}
+	var readsConfigFile bool
 
 	switch {
 	case binaryPath != "" && strings.HasSuffix(binaryPath, ".dll"):
 		// Portable pre-compiled dll: run `dotnet <name>.dll`
 		args = append(args, binaryPath)
+		readsConfigFile = binaryReadsConfigFile(binaryPath)
 	case binaryPath != "":
 		// Self-contained executable: run it directly.
 		executable = binaryPath
+		readsConfigFile = binaryReadsConfigFile(binaryPath)
 	default:
 		// Run from source.
-		args = append(args, "run")
-
 		// If we are certain the project has been built,
 		// passing a --no-build flag to dotnet run results in
 		// up to 1s time savings.
-		if host.dotnetBuildSucceeded {
-			args = append(args, "--no-build")
+		project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
+		if err != nil {
+			return nil, err
+		}
+		noBuild := host.projectBuildUpToDate(ctx, opts, project)
+		if !noBuild && opts.binlog != "" && !isFileBasedProgram(project) {
+			// `dotnet run` doesn't take -bl, so build separately to get a binary log of the build.
+			engineClient, closer, err := host.connectToEngine()
+			if err != nil {
+				return nil, err
+			}
+			err = host.buildProject(ctx, opts, engineClient, project, req.Info.ProgramDirectory)
+			contract.IgnoreClose(closer)
+			if err != nil {
+				return &pulumirpc.RunResponse{Error: err.Error()}, nil
+			}
+			noBuild = true
 		}
+		args = append(args, opts.runArgs(project, noBuild)...)
+		readsConfigFile = projectReadsConfigFile(project)
+	}
 
-		project := resolveProjectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
-		args = append(args, "--project", project)
+	// SDKs that can read the configuration from a file get it that way, and not in the environment.
+	var configFile string
+	if readsConfigFile {
+		configFile, err = writeConfigFile(config, configSecretKeys)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to write configuration file")
+		}
+		defer os.Remove(configFile)
+		config, configSecretKeys = "", ""
 	}
 
 	if logging.V(5).Enabled() {
@@ -783,33 +1174,24 @@ func (host *dotnetLanguageHost) Run(ctx context.Context, req *pulumirpc.RunReque
 		logging.V(5).Infoln("Language host launching process: ", opts.dotnetExec, commandStr)
 	}
 
-	tracer := otel.Tracer("pulumi-language-dotnet")
-	ctx, otelSpan := cmdutil.StartSpan(ctx, tracer, "execDotnet",
-		trace.WithAttributes(
-			attribute.String("component", "exec.Command"),
-			attribute.StringSlice("args", args),
-		))
-	defer otelSpan.End()
-
-	cmd := exec.CommandContext(ctx, executable, args...) //nolint:gas // intentionally running dynamic program name.
+	// dotnetCommand traces the program's process along with its arguments.
+	cmd, release := dotnetCommand(ctx, executable, args...)
+	defer release()
 
 	// Now simply spawn a process to execute the requested program, wiring up stdout/stderr directly.
 	var errResult string
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Dir = req.Info.ProgramDirectory
-	env := host.constructEnv(req, config, configSecretKeys)
+	env := host.constructEnv(req, config, configSecretKeys, configFile)
+	env = append(env, opts.buildServerEnv()...)
+	env = append(env, opts.debugger.env()...)
 
-	carrier := make(propagation.MapCarrier)
-	otel.GetTextMapPropagator().Inject(ctx, carrier)
-	if traceparent := carrier.Get("traceparent"); traceparent != "" {
-		env = append(env, "TRACEPARENT="+traceparent)
-	}
 	if host.otelEndpoint != "" {
 		env = append(env, "PULUMI_OTEL_EXPORTER_OTLP_ENDPOINT="+host.otelEndpoint)
 	}
 
-	cmd.Env = env
+	setCommandEnv(cmd, env)
 	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
@@ -824,7 +1206,7 @@ func (host *dotnetLanguageHost) Run(ctx context.Context, req *pulumirpc.RunReque
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 		go func() {
-			err = startDebugging(ctx, engineClient, cmd)
+			err = startDebugging(ctx, engineClient, cmd, opts.debugger)
 			if err != nil {
 				// kill the program if we can't start debugging.
 				logging.Errorf("Unable to start debugging: %v", err)
@@ -860,33 +1242,11 @@ func (host *dotnetLanguageHost) Run(ctx context.Context, req *pulumirpc.RunReque
 	return &pulumirpc.RunResponse{Error: errResult}, nil
 }
 
-func startDebugging(ctx context.Context, engineClient pulumirpc.EngineClient, cmd *exec.Cmd) error {
-	// wait for the debugger to be ready
-	ctx, cancel := context.WithTimeoutCause(ctx, 1*time.Minute, errors.New("debugger startup timed out"))
-	defer cancel()
-	// wait for the debugger to be ready
-
-	debugConfig, err := structpb.NewStruct(map[string]interface{}{
-		"name":      "Pulumi: Program (Dotnet)",
-		"type":      "coreclr",
-		"request":   "attach",
-		"processId": cmd.Process.Pid,
-	})
-	if err != nil {
-		return err
-	}
-	_, err = engineClient.StartDebugging(ctx, &pulumirpc.StartDebuggingRequest{
-		Config:  debugConfig,
-		Message: fmt.Sprintf("on process id %d", cmd.Process.Pid),
-	})
-	if err != nil {
-		return fmt.Errorf("unable to start debugging: %w", err)
-	}
-
-	return nil
-}
-
-func (host *dotnetLanguageHost) constructEnv(req *pulumirpc.RunRequest, config, configSecretKeys string) []string {
+// constructEnv returns the environment to run the program in.  Its configuration is either in config and
+// configSecretKeys, or in configFile for SDKs that read it from there.
+func (host *dotnetLanguageHost) constructEnv(
+	req *pulumirpc.RunRequest, config, configSecretKeys, configFile string,
+) []string {
 	env := os.Environ()
 
 	maybeAppendEnv := func(k, v string) {
@@ -910,6 +1270,7 @@ func (host *dotnetLanguageHost) constructEnv(req *pulumirpc.RunRequest, config,
 	maybeAppendEnv("tracing", host.tracing)
 	maybeAppendEnv("config", config)
 	maybeAppendEnv("config_secret_keys", configSecretKeys)
+	maybeAppendEnv("config_file", configFile)
 	maybeAppendEnv("attach_debugger", strconv.FormatBool(req.GetAttachDebugger()))
 
 	return env
@@ -962,24 +1323,52 @@ func (host *dotnetLanguageHost) InstallDependencies(
 	// best effort close, but we try an explicit close and error check at the end as well
 	defer closer.Close()
 
-	tracer := otel.Tracer("pulumi-language-dotnet")
-	_, otelSpan := cmdutil.StartSpan(server.Context(), tracer, "dotnet-build")
+	tracer := otel.Tracer(tracerName)
+	ctx, otelSpan := cmdutil.StartSpan(server.Context(), tracer, "dotnet-build")
 	defer otelSpan.End()
 
 	stdout.Write([]byte("Installing dependencies...\n\n"))
 
-	dotnetbin, err := executable.FindExecutable("dotnet")
+	opts, err := parseOptions(req.Info.RootDirectory, req.Info.Options.AsMap())
 	if err != nil {
 		return err
 	}
+	if opts.dotnetExec == "" {
+		stdout.Write([]byte("Nothing to install for a self-contained binary\n\n"))
+		return closer.Close()
+	}
+	ctx = opts.withBinlogs(ctx, "InstallDependencies", stdout)
 
-	project := resolveProjectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
-	cmd := exec.CommandContext(server.Context(), dotnetbin, "build", project)
-	cmd.Dir = req.Info.ProgramDirectory
-	cmd.Stdout, cmd.Stderr = stdout, stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("`dotnet build` failed to install dependencies: %w", err)
+	project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
+	if err != nil {
+		return err
+	}
+	for _, phase := range opts.installPhases(project, req.Info.ProgramDirectory) {
+		stdout.Write([]byte(phase.description + "...\n"))
+		var cache *buildCache
+		var output bytes.Buffer
+		cmd, release := dotnetCommand(ctx, opts.dotnetExec, phase.args...)
+		cmd.Dir = req.Info.ProgramDirectory
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		if phase.command == "build" {
+			// Record the build as if DotnetBuild had run it, so that Run doesn't build again.  Not restoring doesn't
+			// change what's built, the packages were just restored.
+			if cache, err = newBuildCache(ctx, opts.dotnetExec, project, opts.buildArgs(project)); err != nil {
+				logging.V(5).Infof("not caching the build of %s: %v", project, err)
+			}
+			cmd.Stdout = io.MultiWriter(stdout, &output)
+		}
+		err := cmd.Run()
+		release()
+		if err != nil {
+			return fmt.Errorf("`dotnet %s` failed to install dependencies: %w", phase.command, err)
+		}
+		if cache != nil {
+			if err := cache.record(output.String()); err != nil {
+				logging.V(5).Infof("could not record the build of %s: %v", project, err)
+			}
+		}
+		stdout.Write([]byte("\n"))
 	}
 	stdout.Write([]byte("Finished installing dependencies\n\n"))
 
@@ -993,37 +1382,47 @@ func (host *dotnetLanguageHost) InstallDependencies(
 func (host *dotnetLanguageHost) RuntimeOptionsPrompts(ctx context.Context,
 	req *pulumirpc.RuntimeOptionsRequest,
 ) (*pulumirpc.RuntimeOptionsResponse, error) {
-	return &pulumirpc.RuntimeOptionsResponse{}, nil
+	prompts, err := runtimeOptionsPrompts(
+		req.Info.GetProgramDirectory(), req.Info.GetEntryPoint(), req.Info.GetOptions().AsMap())
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.RuntimeOptionsResponse{Prompts: prompts}, nil
 }
 
 func (host *dotnetLanguageHost) About(
 	ctx context.Context, req *pulumirpc.AboutRequest,
 ) (*pulumirpc.AboutResponse, error) {
-	getResponse := func(execString string, args ...string) (string, string, error) {
-		ex, err := executable.FindExecutable(execString)
-		if err != nil {
-			return "", "", fmt.Errorf("could not find executable '%s': %w", execString, err)
-		}
-		cmd := exec.CommandContext(ctx, ex, args...)
-		var out []byte
-		if out, err = cmd.Output(); err != nil {
-			cmd := ex
-			if len(args) != 0 {
-				cmd += " " + strings.Join(args, " ")
-			}
-			return "", "", fmt.Errorf("failed to execute '%s'", cmd)
+	opts, err := parseOptions(req.GetInfo().GetRootDirectory(), req.GetInfo().GetOptions().AsMap())
+	if err != nil {
+		// `pulumi about` is what gets run to report broken options, so describe the defaults rather than fail.
+		logging.V(3).Infof("About: ignoring invalid runtime options: %v", err)
+		opts = dotnetOptions{}
+	}
+	dotnet := opts.dotnetExec
+	if dotnet == "" {
+		// A self-contained binary doesn't need the SDK to run, but it's still what builds it.
+		if dotnet, err = exec.LookPath("dotnet"); err != nil {
+			return nil, fmt.Errorf("could not find executable 'dotnet': %w", err)
 		}
-		return ex, strings.TrimSpace(string(out)), nil
 	}
 
-	dotnet, version, err := getResponse("dotnet", "--version")
+	// The SDK used depends on the global.json in effect for the program.
+	programDirectory := req.GetInfo().GetProgramDirectory()
+	version, err := dotnetOutput(ctx, dotnet, programDirectory, "--version")
 	if err != nil {
 		return nil, err
 	}
 
+	projectFile, err := opts.projectPath(programDirectory, req.GetInfo().GetEntryPoint())
+	if err != nil {
+		// There's still plenty to say about the SDK.
+		logging.V(5).Infof("About: %v", err)
+	}
 	return &pulumirpc.AboutResponse{
 		Executable: dotnet,
 		Version:    version,
+		Metadata:   aboutMetadata(ctx, dotnet, programDirectory, projectFile),
 	}, nil
 }
 
@@ -1040,54 +1439,30 @@ func (host *dotnetLanguageHost) GetProgramDependencies(
 	if opts.binary != "" {
 		return nil, errors.New("Could not get dependencies because pulumi specifies a binary")
 	}
-	var ex string
-	var out []byte
-	ex, err = executable.FindExecutable("dotnet")
+	project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
 	if err != nil {
 		return nil, err
 	}
-	project := resolveProjectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
-	cmdArgs := []string{"list", project, "package"}
-	if req.TransitiveDependencies {
-		cmdArgs = append(cmdArgs, "--include-transitive")
-	}
-	cmd := exec.CommandContext(ctx, ex, cmdArgs...)
-	cmd.Dir = req.Info.ProgramDirectory
-	if out, err = cmd.Output(); err != nil {
-		return nil, fmt.Errorf("failed to call \"%s\": %w", ex, err)
-	}
-	lines := strings.Split(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n")
-	var packages []*pulumirpc.DependencyInfo
-
-	for _, p := range lines {
-		p := strings.TrimSpace(p)
-		if strings.HasPrefix(p, ">") {
-			p = strings.TrimPrefix(p, "> ")
-			segments := strings.Split(p, " ")
-			var nameRequiredVersion []string
-			for _, s := range segments {
-				if s != "" {
-					nameRequiredVersion = append(nameRequiredVersion, s)
-				}
-			}
-			var version int
-			if len(nameRequiredVersion) == 3 {
-				// Top level package => name required version
-				version = 2
-			} else if len(nameRequiredVersion) == 2 {
-				// Transitive package => name version
-				version = 1
-			} else {
-				return nil, fmt.Errorf("failed to parse \"%s\"", p)
-			}
-			packages = append(packages, &pulumirpc.DependencyInfo{
-				Name:    nameRequiredVersion[0],
-				Version: nameRequiredVersion[version],
-			})
+	var list *packageList
+	if isFileBasedProgram(project) {
+		// Only the packages the file asks for are known, not their dependencies.
+		packageDirs, err := resolvePackageFolders(project)
+		if err != nil {
+			return nil, err
+		}
+		list, err = fileProgramPackages(project, packageDirs)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		list, err = listPackages(ctx, opts.dotnetExec, nil, project, req.Info.ProgramDirectory, req.TransitiveDependencies)
+		if err != nil {
+			return nil, err
 		}
 	}
+
 	return &pulumirpc.GetProgramDependenciesResponse{
-		Dependencies: packages,
+		Dependencies: programDependencies(list),
 	}, nil
 }
 
@@ -1108,17 +1483,25 @@ func (host *dotnetLanguageHost) RunPlugin(
 		return err
 	}
 
+	ctx := opts.withBinlogs(server.Context(), "RunPlugin", stderr)
+
 	binaryPath := opts.binary
 	if req.GetAttachDebugger() && opts.binary == "" {
 		var err error
-		binaryPath, err = buildDebuggingDLL(server.Context(),
-			opts.dotnetExec, req.GetInfo().GetRootDirectory(), req.GetInfo().GetEntryPoint())
+		binaryPath, err = buildDebuggingDLL(ctx,
+			opts, req.GetInfo().GetRootDirectory(), req.GetInfo().GetEntryPoint())
+		if err != nil {
+			return err
+		}
+	} else if opts.binary == "" && opts.publish != "" {
+		project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
 		if err != nil {
 			return err
 		}
-		binaryPath = filepath.Join(req.GetInfo().GetRootDirectory(), binaryPath)
-		if binaryPath == "" {
-			return errors.New("failed to find project file, and could not start debugging")
+		// The plugin's stdout is for talking to the engine, so the publish output all goes to stderr.
+		binaryPath, err = publishProgram(ctx, opts, project, req.Pwd, stderr, stderr)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -1136,41 +1519,30 @@ func (host *dotnetLanguageHost) RunPlugin(
 		// Build from source and then run. We build separately so that we can elide the build output from the
 		// user unless there's an error. You would think you could pass something like `-v=q` to `dotnet run`
 		// to get the same effect, but it doesn't work.
-		project := resolveProjectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
-
-		buildArgs := []string{"build", project}
-
-		if logging.V(5).Enabled() {
-			commandStr := strings.Join(buildArgs, " ")
-			logging.V(5).Infoln("Language host launching process: ", executable, " ", commandStr)
+		project, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
+		if err != nil {
+			return err
 		}
 
-		cmd := exec.CommandContext(
-			server.Context(), executable, buildArgs...) //nolint:gas // intentionally running dynamic program name.
-		cmd.Dir = req.Pwd
-		cmd.Env = req.Env
-		var buildOutput bytes.Buffer
-		cmd.Stdout, cmd.Stderr = &buildOutput, &buildOutput
-		if err = cmd.Run(); err != nil {
-			// Build failed for some reason.  Dump the output to the user so they can see what went wrong.
-			stderr.Write(buildOutput.Bytes())
-
-			if exiterr, ok := err.(*exec.ExitError); ok {
-				if status, stok := exiterr.Sys().(syscall.WaitStatus); stok {
-					err = errors.Errorf("Build exited with non-zero exit code: %d", status.ExitStatus())
-				} else {
-					err = errors.Wrapf(exiterr, "Build exited unexpectedly")
+		if opts.watch {
+			err := newPluginWatcher(opts, project, req, stdout, stderr).watch(ctx)
+			var notWatchable *notWatchableError
+			if !errors.As(err, &notWatchable) {
+				if err != nil {
+					return err
 				}
-			} else {
-				// Otherwise, we didn't even get to run the build. This ought to never happen unless there's
-				// a bug or system condition that prevented us from running the language exec. Issue a scarier error.
-				err = errors.Wrapf(err, "Problem building plugin program (could not run language executor)")
+				return closer.Close()
 			}
+			// Better to run the plugin unwatched than not at all.
+			fmt.Fprintf(stderr, "%v, running the plugin without watching it\n", err)
+		}
+		if err := buildPlugin(ctx, opts, project, req.Pwd, req.Env, stderr); err != nil {
 			return err
 		}
 
 		// Now run from source without re-building.
-		args = append(args, "run", "--no-build", "--project", project, "--")
+		args = append(args, opts.runArgs(project, true /*noBuild*/)...)
+		args = append(args, "--")
 	}
 
 	// Add on all the request args to start this plugin
@@ -1182,14 +1554,15 @@ func (host *dotnetLanguageHost) RunPlugin(
 	}
 
 	// Now simply spawn a process to execute the requested program, wiring up stdout/stderr directly.
-	cmd := exec.CommandContext(
-		server.Context(), executable, args...) //nolint:gas // intentionally running dynamic program name.
+	cmd, release := dotnetCommand(server.Context(), executable, args...)
+	defer release()
 	cmd.Dir = req.Pwd
 
-	cmd.Env = req.Env
+	env := append(req.Env, opts.debugger.env()...)
 	if req.GetAttachDebugger() {
-		cmd.Env = append(req.Env, "PULUMI_ATTACH_DEBUGGER=true")
+		env = append(env, "PULUMI_ATTACH_DEBUGGER=true")
 	}
+	setCommandEnv(cmd, env)
 	cmd.Stdout, cmd.Stderr = stdout, stderr
 	if err := cmd.Start(); err != nil {
 		return err
@@ -1205,7 +1578,7 @@ func (host *dotnetLanguageHost) RunPlugin(
 		ctx, cancel := context.WithCancel(server.Context())
 		defer cancel()
 		go func() {
-			err = startDebugging(ctx, engineClient, cmd)
+			err = startDebugging(ctx, engineClient, cmd, opts.debugger)
 			if err != nil {
 				// kill the program if we can't start debugging.
 				logging.Errorf("Unable to start debugging: %v", err)
@@ -1241,42 +1614,66 @@ func (host *dotnetLanguageHost) RunPlugin(
 	return nil
 }
 
-func (host *dotnetLanguageHost) Pack(ctx context.Context, req *pulumirpc.PackRequest) (*pulumirpc.PackResponse, error) {
-	// copy the source to the target.
-	projectFile := ""
-	err := filepath.Walk(req.PackageDirectory, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if filepath.Ext(path) == ".csproj" {
-			projectFile = strings.TrimSuffix(path, ".csproj")
+// buildPlugin builds the plugin project separately from running it, so that the build output can be elided from the
+// user unless there's an error, in which case it is written to stderr.
+func buildPlugin(ctx context.Context, opts dotnetOptions, project, dir string, env []string, stderr io.Writer) error {
+	buildArgs := append([]string{"build", project}, opts.msbuildArgs()...)
+	buildArgs = append(buildArgs, opts.nodeReuseArgs()...)
+
+	if logging.V(5).Enabled() {
+		commandStr := strings.Join(buildArgs, " ")
+		logging.V(5).Infoln("Language host launching process: ", opts.dotnetExec, " ", commandStr)
+	}
+
+	cmd, release := dotnetCommand(ctx, opts.dotnetExec, buildArgs...)
+	defer release()
+	cmd.Dir = dir
+	setCommandEnv(cmd, env)
+	var buildOutput bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &buildOutput, &buildOutput
+	if err := cmd.Run(); err != nil {
+		// Build failed for some reason.  Dump the output to the user so they can see what went wrong.
+		stderr.Write(buildOutput.Bytes())
+
+		if exiterr, ok := err.(*exec.ExitError); ok {
+			if status, stok := exiterr.Sys().(syscall.WaitStatus); stok {
+				return errors.Errorf("Build exited with non-zero exit code: %d", status.ExitStatus())
+			}
+			return errors.Wrapf(exiterr, "Build exited unexpectedly")
 		}
-		return nil
-	})
+		// Otherwise, we didn't even get to run the build. This ought to never happen unless there's
+		// a bug or system condition that prevented us from running the language exec. Issue a scarier error.
+		return errors.Wrapf(err, "Problem building plugin program (could not run language executor)")
+	}
+	return nil
+}
 
-	if err != nil || projectFile == "" {
-		return nil, fmt.Errorf("find project file: %w", err)
+func (host *dotnetLanguageHost) Pack(ctx context.Context, req *pulumirpc.PackRequest) (*pulumirpc.PackResponse, error) {
+	projectFile, err := findPackableProject(req.PackageDirectory)
+	if err != nil {
+		return nil, err
 	}
+	projectDir := filepath.Dir(projectFile)
 
 	// Get the default options so we can get the dotnet executable to use
 	opts, err := parseOptions(req.PackageDirectory, nil)
 	if err != nil {
 		return nil, err
 	}
+	ctx = opts.withBinlogs(ctx, "Pack", os.Stderr)
 
 	build := func() ([]byte, error) {
-		err := os.RemoveAll(filepath.Join(req.PackageDirectory, "bin"))
+		err := os.RemoveAll(filepath.Join(projectDir, "bin"))
 		if err != nil {
 			return nil, err
 		}
-		err = os.RemoveAll(filepath.Join(req.PackageDirectory, "obj"))
+		err = os.RemoveAll(filepath.Join(projectDir, "obj"))
 		if err != nil {
 			return nil, err
 		}
 
-		cmd := exec.CommandContext( //nolint:gosec // intentionally running dynamic program name.
-			ctx,
-			opts.dotnetExec, "build", "-c", "Release")
+		cmd, release := dotnetCommand(ctx, opts.dotnetExec, "build", projectFile, "-c", "Release")
+		defer release()
 		cmd.Dir = req.PackageDirectory
 		return cmd.CombinedOutput()
 	}
@@ -1285,21 +1682,22 @@ func (host *dotnetLanguageHost) Pack(ctx context.Context, req *pulumirpc.PackReq
 		return nil, errutil.ErrorWithStderr(err, "build error before pack.  stdout: "+string(out))
 	}
 
-	destination := filepath.Join(req.DestinationDirectory, filepath.Base(projectFile))
+	destination := filepath.Join(req.DestinationDirectory,
+		strings.TrimSuffix(filepath.Base(projectFile), filepath.Ext(projectFile)))
 
-	// Pack into a temporary directory first so that the Walk below only finds the nupkg
+	// Pack into a temporary directory first so that the search below only finds the packages
 	// from this specific pack operation. Without this, multiple versions of the same
 	// package (e.g. Pulumi.Simple 2.0.0 and 27.0.0) would all output to the same
-	// destination directory, and the Walk would pick the wrong nupkg.
+	// destination directory, and the search would pick the wrong nupkg.
 	packDir, err := os.MkdirTemp("", "dotnet-pack-*")
 	if err != nil {
 		return nil, fmt.Errorf("create temp pack dir: %w", err)
 	}
 	defer os.RemoveAll(packDir)
 
-	cmd := exec.CommandContext( //nolint:gosec // intentionally running dynamic program name.
-		ctx,
-		opts.dotnetExec, "pack", "-c", "Release", "-o", packDir, "-p:IncludeSource", "-p:SymbolPackageFormat=snupkg")
+	cmd, release := dotnetCommand(ctx, opts.dotnetExec, "pack", projectFile,
+		"-c", "Release", "-o", packDir, "--include-symbols", "-p:IncludeSource", "-p:SymbolPackageFormat=snupkg")
+	defer release()
 	cmd.Dir = req.PackageDirectory
 
 	output, err := cmd.CombinedOutput()
@@ -1307,34 +1705,40 @@ func (host *dotnetLanguageHost) Pack(ctx context.Context, req *pulumirpc.PackReq
 		return nil, fmt.Errorf("failed to pack: %w. Dotnet pack output:\n%s", err, string(output))
 	}
 
-	// Find the nupkg in the temp directory, then move it to the real destination.
-	var nugetFilePath string
-	err = filepath.Walk(packDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if filepath.Ext(path) == ".nupkg" {
-			nugetFilePath = path
-		}
-		return nil
-	})
-
-	if err != nil || nugetFilePath == "" {
+	// Find the nupkg and its symbols package in the temp directory.
+	entries, err := os.ReadDir(packDir)
+	if err != nil {
 		return nil, fmt.Errorf("couldn't find packed nuget: %w", err)
 	}
+	var nugetFilePath, symbolsFilePath string
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".nupkg":
+			nugetFilePath = filepath.Join(packDir, entry.Name())
+		case ".snupkg":
+			symbolsFilePath = filepath.Join(packDir, entry.Name())
+		}
+	}
+	if nugetFilePath == "" {
+		return nil, fmt.Errorf("couldn't find packed nuget in the output of dotnet pack:\n%s", string(output))
+	}
 
-	// Copy the nupkg to the destination directory so it's available as a NuGet restore source.
+	// Copy the packages to the destination directory so they're available as a NuGet restore source, with the
+	// symbols next to the package as NuGet expects.
 	if err := os.MkdirAll(destination, 0o755); err != nil {
 		return nil, fmt.Errorf("create destination dir: %w", err)
 	}
 	finalPath := filepath.Join(destination, filepath.Base(nugetFilePath))
-	data, err := os.ReadFile(nugetFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("read packed nupkg: %w", err)
-	}
-	if err := os.WriteFile(finalPath, data, 0o600); err != nil {
+	if err := copyFile(nugetFilePath, finalPath); err != nil {
 		return nil, fmt.Errorf("write nupkg to destination: %w", err)
 	}
+	if symbolsFilePath != "" {
+		symbolsPath := filepath.Join(destination, filepath.Base(symbolsFilePath))
+		if err := copyFile(symbolsFilePath, symbolsPath); err != nil {
+			return nil, fmt.Errorf("write snupkg to destination: %w", err)
+		}
+		logging.V(5).Infof("Pack: symbols for %s are in %s", finalPath, symbolsPath)
+	}
 
 	return &pulumirpc.PackResponse{
 		ArtifactPath: finalPath,
@@ -1371,6 +1775,29 @@ func (host *dotnetLanguageHost) GeneratePackage(
 	if err != nil {
 		return nil, err
 	}
+	if propsFile := dotnetcodegen.CentralPackageVersionsFile(req.Directory); propsFile != "" {
+		// Versions in the project file would fail the restore with NU1008, so they're moved to the props file,
+		// which codegen leaves to us to write.
+		info, err := os.Stat(propsFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not update %s: %w", propsFile, err)
+		}
+		props, err := os.ReadFile(propsFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not update %s: %w", propsFile, err)
+		}
+		updated := props
+		for filename, data := range files {
+			if filepath.Ext(filename) == ".csproj" {
+				files[filename], updated = dotnetcodegen.UseCentralPackageVersions(data, updated)
+			}
+		}
+		if !bytes.Equal(updated, props) {
+			if err := os.WriteFile(propsFile, updated, info.Mode().Perm()); err != nil {
+				return nil, fmt.Errorf("could not update %s: %w", propsFile, err)
+			}
+		}
+	}
 
 	for filename, data := range files {
 		outPath := filepath.Join(req.Directory, filename)
@@ -1499,23 +1926,33 @@ func (host *dotnetLanguageHost) Link(
 	defer loader.Close()
 	cachedLoader := schema.NewCachedLoader(loader)
 
-	var instructions strings.Builder
-	instructions.WriteString("Add the following to your .csproj file of the program:\n")
-	instructions.WriteString("\n")
-	instructions.WriteString("  <DefaultItemExcludes>$(DefaultItemExcludes);sdks/**/*.cs</DefaultItemExcludes>\n")
-	instructions.WriteString("\n")
-	if len(req.Packages) == 1 {
-		instructions.WriteString("You can then use the SDK in your .NET code with:\n")
-	} else {
-		instructions.WriteString("You can then use the SDKs in your .NET code with:\n")
+	var opts dotnetOptions
+	if opts.project, err = stringOption(req.Info.Options.AsMap(), "project"); err != nil {
+		return nil, err
+	}
+	projectFile, err := opts.projectPath(req.Info.ProgramDirectory, req.Info.EntryPoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding the program's project")
 	}
 
+	var instructions strings.Builder
 	for _, dep := range req.Packages {
-		cmd := exec.Command("dotnet", "add", "reference", dep.Path) //nolint:gosec
-		cmd.Stderr = os.Stderr
-		cmd.Stdout = os.Stdout
-		if err := cmd.Run(); err != nil {
-			return nil, fmt.Errorf("dotnet error: %w", err)
+		// Paths are relative to the root of the Pulumi project.
+		path := dep.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(req.Info.RootDirectory, path)
+		}
+		link := linkProjectDependency
+		if isFileBasedProgram(projectFile) {
+			link = linkFileProgramDependency
+		}
+		if err := link(projectFile, path); err != nil {
+			return nil, errors.Wrapf(err, "linking %s into %s", dep.Path, projectFile)
+		}
+
+		if dep.Package.Name == "pulumi" {
+			// The core SDK has no schema, and is imported as `Pulumi` already.
+			continue
 		}
 
 		var version *semver.Version
@@ -1570,10 +2007,8 @@ func (host *dotnetLanguageHost) Link(
 				}
 			}
 		}
-		instructions.WriteString("\n")
-		instructions.WriteString(fmt.Sprintf("  using %s.%s;\n", csharpPackageName(namespace), csharpPackageName(pkg.Name)))
+		fmt.Fprintf(&instructions, "using %s.%s;\n", csharpPackageName(namespace), csharpPackageName(pkg.Name))
 	}
-	instructions.WriteString("\n")
 
 	return &pulumirpc.LinkResponse{
 		ImportInstructions: instructions.String(),
@@ -16,6 +16,7 @@ package main
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -167,7 +168,7 @@ func TestDeterminePackageDependency(t *testing.T) {
 				c.Expected.Kind = "resource"
 			}
 
-			actual, err := DeterminePackageDependency(cwd, c.PackageName, c.PackageVersion)
+			actual, _, err := determinePackageDependencies([]string{cwd}, c.PackageName, c.PackageVersion)
 
 			if c.ExpectError {
 				t.Logf("Error expected")
@@ -181,6 +182,93 @@ func TestDeterminePackageDependency(t *testing.T) {
 	}
 }
 
+func TestDeterminePackageDependencies(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name         string
+		PulumiPlugin string
+		ExpectError  string
+		Expected     []*pulumirpc.PackageDependency
+	}{
+		{
+			Name:         "resource",
+			PulumiPlugin: `{"resource": true, "name": "aws", "version": "6.0.0"}`,
+			Expected: []*pulumirpc.PackageDependency{
+				{Kind: "resource", Name: "aws", Version: "6.0.0"},
+			},
+		},
+		{
+			Name:         "analyzer",
+			PulumiPlugin: `{"kind": "analyzer", "name": "aws-guard", "server": "github://api.github.com/acme"}`,
+			Expected: []*pulumirpc.PackageDependency{
+				{Kind: "analyzer", Name: "aws-guard", Version: "1.2.3", Server: "github://api.github.com/acme"},
+			},
+		},
+		{
+			Name: "only other plugins",
+			PulumiPlugin: `{"resource": false, "plugins": [
+				{"kind": "converter", "name": "terraform", "version": "1.0.0"},
+				{"kind": "tool", "name": "esc"}
+			]}`,
+			Expected: []*pulumirpc.PackageDependency{
+				{Kind: "converter", Name: "terraform", Version: "1.0.0"},
+				{Kind: "tool", Name: "esc"},
+			},
+		},
+		{
+			Name: "resource and other plugins",
+			PulumiPlugin: `{"resource": true, "plugins": [
+				{"kind": "analyzer", "name": "policies", "version": "v0.1.0"}
+			]}`,
+			Expected: []*pulumirpc.PackageDependency{
+				{Kind: "resource", Name: "acme", Version: "1.2.3"},
+				{Kind: "analyzer", Name: "policies", Version: "v0.1.0"},
+			},
+		},
+		{
+			Name:         "invalid kind",
+			PulumiPlugin: `{"kind": "provider"}`,
+			ExpectError:  `invalid plugin kind "provider"`,
+		},
+		{
+			Name:         "invalid plugin kind",
+			PulumiPlugin: `{"plugins": [{"kind": "policy", "name": "policies"}]}`,
+			ExpectError:  `invalid kind "policy" for plugin policies`,
+		},
+		{
+			Name:         "plugin without a name",
+			PulumiPlugin: `{"plugins": [{"kind": "tool"}]}`,
+			ExpectError:  "plugins must have a name",
+		},
+		{
+			Name:         "invalid plugin version",
+			PulumiPlugin: `{"plugins": [{"kind": "tool", "name": "esc", "version": "latest"}]}`,
+			ExpectError:  "invalid version for plugin esc",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+
+			cwd := t.TempDir()
+			artifactPath := filepath.Join(cwd, "pulumi.acme", "1.2.3", "content")
+			require.NoError(t, os.MkdirAll(artifactPath, 0o700))
+			require.NoError(t, os.WriteFile(
+				filepath.Join(artifactPath, "pulumi-plugin.json"), []byte(c.PulumiPlugin), 0o600))
+
+			actual, err := DeterminePackageDependencies([]string{cwd}, "Pulumi.Acme", "1.2.3")
+			if c.ExpectError != "" {
+				assert.ErrorContains(t, err, c.ExpectError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, c.Expected, actual)
+			}
+		})
+	}
+}
+
 //nolint:paralleltest // mutates cwd
 func TestBuildDll(t *testing.T) {
 	cases := []struct {
@@ -188,8 +276,9 @@ func TestBuildDll(t *testing.T) {
 		EntryPoint string
 		ExtraSetup func(t *testing.T, e *ptesting.Environment)
 
-		ExpectedErrorContains string
-		ExpectedBinaryPath    string
+		// The entry point that should be reported as a missing project file.
+		ExpectedMissingProject string
+		ExpectedBinaryPath     string
 	}{
 		{
 			Name:               "regular case works",
@@ -202,9 +291,9 @@ func TestBuildDll(t *testing.T) {
 			ExpectedBinaryPath: filepath.Join("bin", "pulumi-debugging", "Empty.dll"),
 		},
 		{
-			Name:                  "entrypoint not found",
-			EntryPoint:            "Wrong.csproj",
-			ExpectedErrorContains: "Project file does not exist",
+			Name:                   "entrypoint not found",
+			EntryPoint:             "Wrong.csproj",
+			ExpectedMissingProject: "Wrong.csproj",
 		},
 		{
 			Name:       "fsproj works",
@@ -215,15 +304,6 @@ func TestBuildDll(t *testing.T) {
 			},
 			ExpectedBinaryPath: filepath.Join("bin", "pulumi-debugging", "Empty.dll"),
 		},
-		{
-			Name:       "vbproj works",
-			EntryPoint: ".",
-			ExtraSetup: func(t *testing.T, e *ptesting.Environment) {
-				err := os.Rename(filepath.Join(e.RootPath, "Empty.csproj"), filepath.Join(e.RootPath, "Empty.vbproj"))
-				require.NoError(t, err)
-			},
-			ExpectedErrorContains: "'Sub Main' was not found in 'Empty'.",
-		},
 		{
 			Name:       "multiple projects with entrypoint",
 			EntryPoint: "Empty.csproj",
@@ -236,9 +316,9 @@ func TestBuildDll(t *testing.T) {
 			ExpectedBinaryPath: filepath.Join("bin", "pulumi-debugging", "Empty.dll"),
 		},
 		{
-			Name:                  "incorrect entry point name",
-			EntryPoint:            "Another.csproj",
-			ExpectedErrorContains: "Project file does not exist",
+			Name:                   "incorrect entry point name",
+			EntryPoint:             "Another.csproj",
+			ExpectedMissingProject: "Another.csproj",
 		},
 	}
 
@@ -253,26 +333,43 @@ func TestBuildDll(t *testing.T) {
 				c.ExtraSetup(t, e)
 			}
 
-			binaryPath, err := buildDebuggingDLL(t.Context(), "dotnet", e.RootPath, c.EntryPoint)
+			binaryPath, err := buildDebuggingDLL(t.Context(), dotnetOptions{dotnetExec: "dotnet"}, e.RootPath, c.EntryPoint)
 
-			if c.ExpectedErrorContains != "" {
-				assert.ErrorContains(t, err, c.ExpectedErrorContains)
+			if c.ExpectedMissingProject != "" {
+				assert.EqualError(t, err,
+					"project file "+filepath.Join(e.RootPath, c.ExpectedMissingProject)+" does not exist")
 			} else {
 				require.NoError(t, err)
-				assert.Equal(t, c.ExpectedBinaryPath, binaryPath)
+				assert.Equal(t, filepath.Join(e.RootPath, c.ExpectedBinaryPath), binaryPath)
 			}
 		})
 	}
+
+	// A vbproj is found like any other project, though the C# in it doesn't build as Visual Basic.
+	t.Run("vbproj is built", func(t *testing.T) {
+		e := ptesting.NewEnvironment(t)
+		e.ImportDirectory("testdata/build-dll")
+		t.Chdir(e.RootPath)
+		project := filepath.Join(e.RootPath, "Empty.vbproj")
+		require.NoError(t, os.Rename(filepath.Join(e.RootPath, "Empty.csproj"), project))
+
+		_, err := buildDebuggingDLL(t.Context(), dotnetOptions{dotnetExec: "dotnet"}, e.RootPath, ".")
+		var exitErr *exec.ExitError
+		assert.ErrorAs(t, err, &exitErr)
+		assert.ErrorContains(t, err, project)
+	})
 }
 
 func TestResolveProjectPath(t *testing.T) {
 	t.Parallel()
 
-	programDirectory := filepath.Join("tmp", "program")
+	programDirectory := t.TempDir()
+	project := filepath.Join(programDirectory, "Infra.csproj")
+	require.NoError(t, os.WriteFile(project, []byte("<Project />"), 0o600))
 
-	assert.Equal(t, programDirectory, resolveProjectPath(programDirectory, ""))
-	assert.Equal(t, programDirectory, resolveProjectPath(programDirectory, "."))
-	assert.Equal(t,
-		filepath.Join(programDirectory, "Infra.csproj"),
-		resolveProjectPath(programDirectory, "Infra.csproj"))
+	for _, entryPoint := range []string{"", ".", "Infra.csproj"} {
+		actual, err := resolveProject(programDirectory, entryPoint)
+		require.NoError(t, err, entryPoint)
+		assert.Equal(t, project, actual, entryPoint)
+	}
 }
@@ -0,0 +1,292 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// nugetConfigFileNames are the names NuGet looks for, in order, in each directory.
+var nugetConfigFileNames = []string{"nuget.config", "NuGet.config", "NuGet.Config"}
+
+// nugetConfig is the subset of a NuGet.Config file that affects where packages are restored to.
+type nugetConfig struct {
+	Config                 *nugetConfigSection `xml:"config"`
+	FallbackPackageFolders *nugetConfigSection `xml:"fallbackPackageFolders"`
+}
+
+type nugetConfigSection struct {
+	Items []nugetConfigItem `xml:",any"`
+}
+
+// nugetConfigItem is an `<add key="..." value="..." />`, `<remove key="..." />` or `<clear />` element.
+type nugetConfigItem struct {
+	XMLName xml.Name
+	Key     string `xml:"key,attr"`
+	Value   string `xml:"value,attr"`
+}
+
+// nugetSetting is a merged configuration value, relative paths are resolved against the file that set it.
+type nugetSetting struct {
+	key   string
+	value string
+}
+
+// resolvePackageFolders determines where NuGet restores packages for the project at the given path (a project file
+// or the directory containing it), following the same rules as NuGet itself.  The global packages folder is
+// returned first, followed by any fallback package folders.
+//
+// The global packages folder is, in order of precedence:
+//
+//  1. the `RestorePackagesPath` MSBuild property of the project,
+//  2. the `NUGET_PACKAGES` environment variable,
+//  3. `globalPackagesFolder` in the closest NuGet.Config that sets it, and
+//  4. `~/.nuget/packages`.
+func resolvePackageFolders(project string) ([]string, error) {
+	projectDir := project
+	if info, err := os.Stat(project); err == nil && !info.IsDir() {
+		projectDir = filepath.Dir(project)
+	}
+	projectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var configSettings, fallbackSettings []nugetSetting
+	for _, path := range nugetConfigFiles(projectDir) {
+		config, err := readNugetConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		if config.Config != nil {
+			configSettings = config.Config.merge(configSettings, path)
+		}
+		if config.FallbackPackageFolders != nil {
+			fallbackSettings = config.FallbackPackageFolders.merge(fallbackSettings, path)
+		}
+	}
+
+	global := ""
+	restorePackagesPath, restoreFallbackFolders := projectRestoreProperties(project)
+	if restorePackagesPath != "" {
+		global = resolveNugetPath(projectDir, restorePackagesPath)
+		logging.V(5).Infof("global packages folder from RestorePackagesPath: %s", global)
+	} else if env := os.Getenv("NUGET_PACKAGES"); env != "" {
+		global, err = filepath.Abs(env)
+		if err != nil {
+			return nil, err
+		}
+		logging.V(5).Infof("global packages folder from NUGET_PACKAGES: %s", global)
+	} else {
+		for _, s := range configSettings {
+			if strings.EqualFold(s.key, "globalPackagesFolder") {
+				global = s.value
+			}
+		}
+		if global != "" {
+			logging.V(5).Infof("global packages folder from NuGet.Config: %s", global)
+		}
+	}
+	if global == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not determine the default NuGet global packages folder")
+		}
+		global = filepath.Join(home, ".nuget", "packages")
+	}
+
+	folders := []string{filepath.Clean(global)}
+	if restoreFallbackFolders != nil {
+		for _, folder := range restoreFallbackFolders {
+			folders = append(folders, resolveNugetPath(projectDir, folder))
+		}
+	} else {
+		for _, s := range fallbackSettings {
+			folders = append(folders, s.value)
+		}
+	}
+	return folders, nil
+}
+
+// nugetConfigFiles returns the NuGet.Config files that apply to a directory, from lowest to highest priority:
+// machine-wide configuration, then the user's configuration, then any files in the directory hierarchy from the
+// root down to dir.
+func nugetConfigFiles(dir string) []string {
+	var files []string
+
+	if machineDir := machineNugetConfigDir(); machineDir != "" {
+		matches, _ := filepath.Glob(filepath.Join(machineDir, "*.config"))
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+
+	if userDir := userNugetConfigDir(); userDir != "" {
+		// Additional user-wide configuration lives next to the main file and has lower priority than it.
+		matches, _ := filepath.Glob(filepath.Join(filepath.Dir(userDir), "config", "*.config"))
+		sort.Strings(matches)
+		files = append(files, matches...)
+		if path := filepath.Join(userDir, "NuGet.Config"); fileExists(path) {
+			files = append(files, path)
+		}
+	}
+
+	var hierarchy []string
+	for d := dir; ; d = filepath.Dir(d) {
+		for _, name := range nugetConfigFileNames {
+			if path := filepath.Join(d, name); fileExists(path) {
+				hierarchy = append(hierarchy, path)
+				break
+			}
+		}
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+	for i := len(hierarchy) - 1; i >= 0; i-- {
+		files = append(files, hierarchy[i])
+	}
+
+	return files
+}
+
+// userNugetConfigDir returns the directory containing the user's NuGet.Config.
+func userNugetConfigDir() string {
+	if runtime.GOOS == "windows" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, "NuGet")
+		}
+		return ""
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".nuget", "NuGet")
+}
+
+// machineNugetConfigDir returns the directory containing machine-wide NuGet configuration files.
+func machineNugetConfigDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		if programFiles := os.Getenv("ProgramFiles(x86)"); programFiles != "" {
+			return filepath.Join(programFiles, "NuGet", "Config")
+		}
+		if programFiles := os.Getenv("ProgramFiles"); programFiles != "" {
+			return filepath.Join(programFiles, "NuGet", "Config")
+		}
+		return ""
+	case "darwin":
+		return filepath.Join("/Library", "Application Support", "NuGet", "Config")
+	default:
+		commonData := os.Getenv("NUGET_COMMON_APPLICATION_DATA")
+		if commonData == "" {
+			commonData = "/etc/opt"
+		}
+		return filepath.Join(commonData, "NuGet", "Config")
+	}
+}
+
+func readNugetConfig(path string) (*nugetConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config nugetConfig
+	if err := xml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", path)
+	}
+	return &config, nil
+}
+
+// merge applies the items of a section from a higher priority file on top of the settings merged so far.
+func (section *nugetConfigSection) merge(settings []nugetSetting, path string) []nugetSetting {
+	for _, item := range section.Items {
+		switch strings.ToLower(item.XMLName.Local) {
+		case "clear":
+			settings = nil
+		case "remove":
+			settings = removeNugetSetting(settings, item.Key)
+		case "add":
+			settings = removeNugetSetting(settings, item.Key)
+			settings = append(settings, nugetSetting{
+				key:   item.Key,
+				value: resolveNugetPath(filepath.Dir(path), item.Value),
+			})
+		}
+	}
+	return settings
+}
+
+func removeNugetSetting(settings []nugetSetting, key string) []nugetSetting {
+	result := settings[:0:0]
+	for _, s := range settings {
+		if !strings.EqualFold(s.key, key) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+var windowsEnvVarRegexp = regexp.MustCompile(`%([^%]+)%`)
+
+// resolveNugetPath expands `%VAR%` environment variables in a path and makes it absolute relative to dir.
+func resolveNugetPath(dir, path string) string {
+	path = windowsEnvVarRegexp.ReplaceAllStringFunc(path, func(m string) string {
+		if v, ok := os.LookupEnv(m[1 : len(m)-1]); ok {
+			return v
+		}
+		return m
+	})
+	path = filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// projectRestoreProperties looks for the `RestorePackagesPath` and `RestoreFallbackFolders` MSBuild properties of a
+// project.
+func projectRestoreProperties(project string) (string, []string) {
+	projectFile, err := findProjectFile(project)
+	if err != nil {
+		return "", nil
+	}
+
+	// A non-nil result means the project overrides the configured fallback folders, `clear` removes them all.
+	var fallbackFolders []string
+	if value := projectProperty(projectFile, "RestoreFallbackFolders"); value != "" {
+		fallbackFolders = []string{}
+		for _, folder := range strings.Split(value, ";") {
+			if folder = strings.TrimSpace(folder); folder != "" && !strings.EqualFold(folder, "clear") {
+				fallbackFolders = append(fallbackFolders, folder)
+			}
+		}
+	}
+	return projectProperty(projectFile, "RestorePackagesPath"), fallbackFolders
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
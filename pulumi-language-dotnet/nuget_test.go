@@ -0,0 +1,155 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:paralleltest // mutates environment variables
+func TestResolvePackageFolders(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("user and machine-wide configuration live elsewhere on Windows")
+	}
+
+	writeFile := func(t *testing.T, path, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	}
+
+	// setup isolates the test from the real user and machine-wide configuration, and returns a solution directory
+	// with a project below it.
+	setup := func(t *testing.T) (string, string, string) {
+		root := t.TempDir()
+		home := filepath.Join(root, "home")
+		t.Setenv("HOME", home)
+		t.Setenv("NUGET_COMMON_APPLICATION_DATA", filepath.Join(root, "machine"))
+		t.Setenv("NUGET_PACKAGES", "")
+		solution := filepath.Join(root, "solution")
+		project := filepath.Join(solution, "src", "Infra")
+		writeFile(t, filepath.Join(project, "Infra.csproj"), `<Project Sdk="Microsoft.NET.Sdk" />`)
+		return root, solution, project
+	}
+
+	t.Run("default", func(t *testing.T) {
+		root, _, project := setup(t)
+		folders, err := resolvePackageFolders(project)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(root, "home", ".nuget", "packages")}, folders)
+	})
+
+	t.Run("closest config wins", func(t *testing.T) {
+		root, solution, project := setup(t)
+		writeFile(t, filepath.Join(root, "machine", "NuGet", "Config", "a.config"), `<configuration>
+  <config><add key="globalPackagesFolder" value="/machine/packages" /></config>
+  <fallbackPackageFolders><add key="machine" value="/machine/fallback" /></fallbackPackageFolders>
+</configuration>`)
+		writeFile(t, filepath.Join(root, "home", ".nuget", "NuGet", "NuGet.Config"), `<configuration>
+  <config><add key="globalPackagesFolder" value="/user/packages" /></config>
+  <fallbackPackageFolders><add key="user" value="/user/fallback" /></fallbackPackageFolders>
+</configuration>`)
+
+		folders, err := resolvePackageFolders(project)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/user/packages", "/machine/fallback", "/user/fallback"}, folders)
+
+		writeFile(t, filepath.Join(solution, "nuget.config"), `<configuration>
+  <config><add key="GlobalPackagesFolder" value="packages" /></config>
+  <fallbackPackageFolders><clear /><add key="solution" value="fallback" /></fallbackPackageFolders>
+</configuration>`)
+
+		folders, err = resolvePackageFolders(filepath.Join(project, "Infra.csproj"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(solution, "packages"),
+			filepath.Join(solution, "fallback"),
+		}, folders)
+	})
+
+	t.Run("environment variable", func(t *testing.T) {
+		root, solution, project := setup(t)
+		writeFile(t, filepath.Join(solution, "NuGet.Config"), `<configuration>
+  <config><add key="globalPackagesFolder" value="packages" /></config>
+</configuration>`)
+		t.Setenv("NUGET_PACKAGES", filepath.Join(root, "env"))
+
+		folders, err := resolvePackageFolders(project)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(root, "env")}, folders)
+	})
+
+	t.Run("project properties", func(t *testing.T) {
+		root, solution, project := setup(t)
+		t.Setenv("NUGET_PACKAGES", filepath.Join(root, "env"))
+		writeFile(t, filepath.Join(solution, "NuGet.Config"), `<configuration>
+  <fallbackPackageFolders><add key="solution" value="fallback" /></fallbackPackageFolders>
+</configuration>`)
+		writeFile(t, filepath.Join(solution, "Directory.Build.props"), `<Project>
+  <PropertyGroup>
+    <RestorePackagesPath>$(MSBuildThisFileDirectory)packages</RestorePackagesPath>
+    <RestoreFallbackFolders>clear</RestoreFallbackFolders>
+  </PropertyGroup>
+</Project>`)
+
+		folders, err := resolvePackageFolders(project)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(solution, "packages")}, folders)
+
+		// The project file itself takes precedence over Directory.Build.props.
+		writeFile(t, filepath.Join(project, "Infra.csproj"), `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <RestorePackagesPath>..\..\local-packages</RestorePackagesPath>
+  </PropertyGroup>
+</Project>`)
+
+		folders, err = resolvePackageFolders(project)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(solution, "local-packages")}, folders)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, solution, project := setup(t)
+		writeFile(t, filepath.Join(solution, "NuGet.Config"), `<configuration>`)
+
+		_, err := resolvePackageFolders(project)
+		assert.ErrorContains(t, err, "could not parse")
+	})
+}
+
+func TestDeterminePackageDependencyFallbackFolders(t *testing.T) {
+	t.Parallel()
+
+	global := t.TempDir()
+	fallback := t.TempDir()
+	artifactPath := filepath.Join(fallback, "pulumi.random", "4.16.0-alpha.1", "content")
+	require.NoError(t, os.MkdirAll(artifactPath, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(artifactPath, "version.txt"), []byte("4.16.0"), 0o600))
+
+	actual, _, err := determinePackageDependencies([]string{global, fallback}, "Pulumi.Random", "4.16.0-Alpha.1")
+	require.NoError(t, err)
+	require.NotNil(t, actual)
+	assert.Equal(t, "random", actual.Name)
+	assert.Equal(t, "v4.16.0", actual.Version)
+
+	actual, _, err = determinePackageDependencies([]string{global}, "Pulumi.Random", "4.16.0-Alpha.1")
+	require.NoError(t, err)
+	assert.Nil(t, actual)
+}
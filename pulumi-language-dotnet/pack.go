@@ -0,0 +1,99 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+var testSdkReferenceRegexp = regexp.MustCompile(`<PackageReference\s+Include\s*=\s*"Microsoft\.NET\.Test\.Sdk"`)
+
+// findPackableProject returns the project in packageDirectory, or in any directory below it, that `Pack` should
+// pack.  Test projects and projects that aren't packable are skipped, and projects that explicitly set `IsPackable`
+// are preferred over those that are packable by default.  Anything else is ambiguous.
+func findPackableProject(packageDirectory string) (string, error) {
+	var explicit, implicit []string
+	err := filepath.WalkDir(packageDirectory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != packageDirectory && (d.Name() == "bin" || d.Name() == "obj" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isProjectFile(path) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		isPackable := strings.ToLower(projectProperty(path, "IsPackable"))
+		switch {
+		case isPackable == "false":
+			logging.V(5).Infof("Pack: skipping %s, it isn't packable", path)
+		case strings.EqualFold(projectProperty(path, "IsTestProject"), "true") || testSdkReferenceRegexp.Match(data):
+			logging.V(5).Infof("Pack: skipping %s, it's a test project", path)
+		case isPackable == "true":
+			explicit = append(explicit, path)
+		default:
+			implicit = append(implicit, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "looking for a project in %s", packageDirectory)
+	}
+
+	candidates := explicit
+	if len(candidates) == 0 {
+		candidates = implicit
+	}
+	switch len(candidates) {
+	case 0:
+		return "", errors.Errorf("no packable .csproj, .fsproj or .vbproj project found in %s", packageDirectory)
+	case 1:
+		return candidates[0], nil
+	}
+	sort.Strings(candidates)
+	for i, candidate := range candidates {
+		if rel, err := filepath.Rel(packageDirectory, candidate); err == nil {
+			candidates[i] = rel
+		}
+	}
+	return "", errors.Errorf("found several packable projects in %s: %s; set <IsPackable>true</IsPackable> in the "+
+		"one to pack, or <IsPackable>false</IsPackable> in the others",
+		packageDirectory, strings.Join(candidates, ", "))
+}
+
+// copyFile copies the file at source to destination, replacing anything already there.
+func copyFile(source, destination string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(destination, data, 0o600)
+}
@@ -0,0 +1,68 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPackableProject(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFile := func(path, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(contents), 0o600))
+	}
+
+	_, err := findPackableProject(root)
+	assert.ErrorContains(t, err, "no packable .csproj, .fsproj or .vbproj project found")
+
+	writeFile("Component.fsproj", `<Project Sdk="Microsoft.NET.Sdk" />`)
+	writeFile("tests/Component.Tests.fsproj", `<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <PackageReference Include="Microsoft.NET.Test.Sdk" Version="17.8.0" />
+  </ItemGroup>
+</Project>`)
+	writeFile("obj/Generated.csproj", `<Project Sdk="Microsoft.NET.Sdk" />`)
+	writeFile("tools/Tool.vbproj", `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <IsPackable>false</IsPackable>
+  </PropertyGroup>
+</Project>`)
+	project, err := findPackableProject(root)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "Component.fsproj"), project)
+
+	writeFile("sample/Sample.csproj", `<Project Sdk="Microsoft.NET.Sdk" />`)
+	_, err = findPackableProject(root)
+	assert.ErrorContains(t, err, "found several packable projects in "+root+": Component.fsproj, "+
+		filepath.Join("sample", "Sample.csproj"))
+
+	// An explicit IsPackable settles it.
+	writeFile("Component.fsproj", `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <IsPackable>True</IsPackable>
+  </PropertyGroup>
+</Project>`)
+	project, err = findPackableProject(root)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "Component.fsproj"), project)
+}
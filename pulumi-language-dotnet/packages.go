@@ -0,0 +1,351 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// packageReference is a single NuGet package reported by `dotnet list package`.
+type packageReference struct {
+	ID string
+	// RequestedVersion is the version (or version range) asked for in the project file. It is empty for
+	// transitive packages.
+	RequestedVersion string
+	// ResolvedVersion is the version NuGet actually restored.
+	ResolvedVersion string
+	Transitive      bool
+	AutoReferenced  bool
+}
+
+// frameworkPackages are the packages a project references for a single target framework.
+type frameworkPackages struct {
+	Framework string
+	Packages  []packageReference
+}
+
+// projectPackages are the packages referenced by a single project, grouped by target framework.
+type projectPackages struct {
+	Path       string
+	Frameworks []frameworkPackages
+}
+
+// packageList is the parsed result of `dotnet list package`.
+type packageList struct {
+	Projects []projectPackages
+}
+
+// listPackages runs `dotnet list package` for the given project and parses its result.
+//
+// The machine readable JSON output of newer .NET SDKs is preferred. If the SDK doesn't understand `--format json`
+// we fall back to scraping the human readable table.
+func listPackages(
+	ctx context.Context,
+	dotnetExec string,
+	engineClient pulumirpc.EngineClient,
+	project string,
+	programDirectory string,
+	transitive bool,
+) (*packageList, error) {
+	args := []string{"list", project, "package"}
+	if transitive {
+		args = append(args, "--include-transitive")
+	}
+
+	// Run the JSON variant quietly and without retries: if it produces no JSON at all we can't tell an old SDK from a
+	// killed process, and either way the text fallback below (which does retry) gets a chance.
+	jsonArgs := append(slices.Clone(args), "--format", "json", "--output-version", "1")
+	logging.V(5).Infoln("Language host launching process: ", dotnetExec, strings.Join(jsonArgs, " "))
+	cmd, release := dotnetCommand(ctx, dotnetExec, jsonArgs...)
+	defer release()
+	cmd.Dir = programDirectory
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	runErr := cmd.Run()
+
+	if output := bytes.TrimSpace(stdout.Bytes()); len(output) > 0 && json.Valid(output) {
+		// `dotnet list package` exits non-zero when it reports problems, but still describes them in the JSON.
+		return parsePackageListJSON(output)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	logging.V(5).Infof("'dotnet %v' did not produce JSON output (%v), falling back to text output: %s",
+		strings.Join(jsonArgs, " "), runErr, stderr.String())
+
+	output, err := runDiscoveryCommand(ctx, dotnetExec, engineClient, args, programDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return parsePackageListText(output)
+}
+
+// listPackageJSON mirrors the `--format json --output-version 1` output of `dotnet list package`.
+type listPackageJSON struct {
+	Version  int `json:"version"`
+	Problems []struct {
+		Project string `json:"project"`
+		Level   string `json:"level"`
+		Text    string `json:"text"`
+	} `json:"problems"`
+	Projects []struct {
+		Path       string `json:"path"`
+		Frameworks []struct {
+			Framework          string                   `json:"framework"`
+			TopLevelPackages   []listPackageJSONPackage `json:"topLevelPackages"`
+			TransitivePackages []listPackageJSONPackage `json:"transitivePackages"`
+		} `json:"frameworks"`
+	} `json:"projects"`
+}
+
+type listPackageJSONPackage struct {
+	ID               string      `json:"id"`
+	RequestedVersion string      `json:"requestedVersion"`
+	ResolvedVersion  string      `json:"resolvedVersion"`
+	AutoReferenced   lenientBool `json:"autoReferenced"`
+}
+
+// lenientBool accepts both JSON booleans and the `"true"`/`"false"` strings some SDK versions write.
+type lenientBool bool
+
+func (b *lenientBool) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseBool(strings.Trim(string(data), `"`))
+	if err != nil {
+		return fmt.Errorf("invalid boolean %s: %w", data, err)
+	}
+	*b = lenientBool(v)
+	return nil
+}
+
+func parsePackageListJSON(data []byte) (*packageList, error) {
+	var output listPackageJSON
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, errors.Wrap(err, "could not parse JSON output of 'dotnet list package'")
+	}
+	if output.Version != 1 {
+		return nil, errors.Errorf("unsupported 'dotnet list package' JSON output version: %d", output.Version)
+	}
+
+	var problems []string
+	for _, p := range output.Problems {
+		text := p.Text
+		if p.Project != "" {
+			text = fmt.Sprintf("%s: %s", p.Project, p.Text)
+		}
+		if strings.EqualFold(p.Level, "error") {
+			problems = append(problems, text)
+		} else {
+			logging.V(5).Infof("'dotnet list package' reported %s: %s", p.Level, text)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("'dotnet list package' reported errors:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	list := &packageList{}
+	for _, p := range output.Projects {
+		project := projectPackages{Path: p.Path}
+		for _, f := range p.Frameworks {
+			framework := frameworkPackages{Framework: f.Framework}
+			for _, pkg := range f.TopLevelPackages {
+				framework.Packages = append(framework.Packages, packageReference{
+					ID:               pkg.ID,
+					RequestedVersion: pkg.RequestedVersion,
+					ResolvedVersion:  pkg.ResolvedVersion,
+					AutoReferenced:   bool(pkg.AutoReferenced),
+				})
+			}
+			for _, pkg := range f.TransitivePackages {
+				framework.Packages = append(framework.Packages, packageReference{
+					ID:              pkg.ID,
+					ResolvedVersion: pkg.ResolvedVersion,
+					Transitive:      true,
+				})
+			}
+			project.Frameworks = append(project.Frameworks, framework)
+		}
+		list.Projects = append(list.Projects, project)
+	}
+	return list, nil
+}
+
+var (
+	// Matches a target framework heading such as `[net8.0]:`, optionally followed by a message like
+	// `No packages were found for this framework.`
+	frameworkHeadingRegexp = regexp.MustCompile(`^\[([^\]]+)\]:`)
+	// Matches the quoted project name in a heading such as `Project 'Aliases' has the following package references`.
+	// Localized SDKs use their own quotation marks.
+	projectHeadingRegexp = regexp.MustCompile("[" + headingQuotes + "]([^" + headingQuotes + "]+)[" + headingQuotes + "]")
+)
+
+const headingQuotes = "'`‘’‚\"“”„«»"
+
+// parsePackageListText parses the human readable table printed by older SDKs that don't support `--format json`:
+//
+//	Project 'Aliases' has the following package references
+//	   [net8.0]:
+//	   Top-level Package      Requested   Resolved
+//	   > Pulumi               3.*         3.60.0
+//	   > Microsoft.NET.ILLink (A)  [8.0.1, )  8.0.1
+//
+//	   Transitive Package     Resolved
+//	   > Google.Protobuf      3.10.0
+//
+// Only the `>` prefix and the `[framework]:` headings are relied on, since everything else may be localized.
+func parsePackageListText(output string) (*packageList, error) {
+	list := &packageList{}
+	var project *projectPackages
+	var framework *frameworkPackages
+
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, ">"):
+			if framework == nil {
+				return nil, errors.Errorf("unexpected output from 'dotnet list package': "+
+					"package %q listed before any target framework", line)
+			}
+			pkg, err := parsePackageLine(line)
+			if err != nil {
+				return nil, err
+			}
+			framework.Packages = append(framework.Packages, pkg)
+		case frameworkHeadingRegexp.MatchString(line):
+			if project == nil {
+				list.Projects = append(list.Projects, projectPackages{})
+				project = &list.Projects[len(list.Projects)-1]
+			}
+			name := frameworkHeadingRegexp.FindStringSubmatch(line)[1]
+			project.Frameworks = append(project.Frameworks, frameworkPackages{Framework: name})
+			framework = &project.Frameworks[len(project.Frameworks)-1]
+		default:
+			// Either a project heading or a column header. Column headers never quote anything.
+			if m := projectHeadingRegexp.FindStringSubmatch(line); m != nil {
+				list.Projects = append(list.Projects, projectPackages{Path: m[1]})
+				project = &list.Projects[len(list.Projects)-1]
+				framework = nil
+			}
+		}
+	}
+
+	return list, nil
+}
+
+// parsePackageLine parses a single `> name [(A)|(D)] [requested] resolved` row of `dotnet list package`. Requested
+// versions may be ranges that contain spaces, such as `[8.0.1, )`.
+func parsePackageLine(line string) (packageReference, error) {
+	fields := strings.Fields(strings.TrimPrefix(line, ">"))
+	if len(fields) < 2 {
+		return packageReference{}, errors.Errorf("unexpected output from 'dotnet list package': could not parse %q", line)
+	}
+
+	pkg := packageReference{ID: fields[0]}
+	var versions []string
+	for i := 1; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == "(A)":
+			pkg.AutoReferenced = true
+		case field == "(D)":
+			// Deprecated marker, nothing to record.
+		case strings.HasPrefix(field, "[") || strings.HasPrefix(field, "("):
+			// A version range, join fields until the closing bracket.
+			for !strings.HasSuffix(field, "]") && !strings.HasSuffix(field, ")") && i+1 < len(fields) {
+				i++
+				field += " " + fields[i]
+			}
+			versions = append(versions, field)
+		default:
+			versions = append(versions, field)
+		}
+	}
+
+	switch len(versions) {
+	case 1:
+		// Transitive package => name resolved
+		pkg.Transitive = true
+		pkg.ResolvedVersion = versions[0]
+	case 2:
+		// Top level package => name requested resolved
+		pkg.RequestedVersion = versions[0]
+		pkg.ResolvedVersion = versions[1]
+	default:
+		return packageReference{}, errors.Errorf("unexpected output from 'dotnet list package': could not parse %q", line)
+	}
+	return pkg, nil
+}
+
+// programDependency is a package as `GetProgramDependencies` reports it: once per resolved version.
+type programDependency struct {
+	id       string
+	version  string
+	topLevel bool
+}
+
+// programDependencies flattens a package list into one dependency per package and resolved version, so packages that
+// resolve differently across projects or target frameworks are listed once per version.  Top-level dependencies, those
+// referenced directly by any of the projects, come first.  Names are the package ids and versions the resolved ones,
+// which is all DependencyInfo has room for.
+func programDependencies(list *packageList) []*pulumirpc.DependencyInfo {
+	var deps []*programDependency
+	byKey := map[string]*programDependency{}
+	for _, p := range list.Projects {
+		for _, f := range p.Frameworks {
+			for _, pkg := range f.Packages {
+				key := strings.ToLower(pkg.ID) + "@" + strings.ToLower(pkg.ResolvedVersion)
+				dep, ok := byKey[key]
+				if !ok {
+					dep = &programDependency{id: pkg.ID, version: pkg.ResolvedVersion}
+					byKey[key] = dep
+					deps = append(deps, dep)
+				}
+				if !pkg.Transitive {
+					dep.topLevel = true
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].topLevel != deps[j].topLevel {
+			return deps[i].topLevel
+		}
+		if a, b := strings.ToLower(deps[i].id), strings.ToLower(deps[j].id); a != b {
+			return a < b
+		}
+		return deps[i].version < deps[j].version
+	})
+
+	result := make([]*pulumirpc.DependencyInfo, 0, len(deps))
+	for _, dep := range deps {
+		result = append(result, &pulumirpc.DependencyInfo{Name: dep.id, Version: dep.version})
+	}
+	return result
+}
@@ -0,0 +1,254 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePackageListJSON(t *testing.T) {
+	t.Parallel()
+
+	output := `{
+  "version": 1,
+  "parameters": "--include-transitive",
+  "projects": [
+    {
+      "path": "/src/Infra/Infra.csproj",
+      "frameworks": [
+        {
+          "framework": "net6.0",
+          "topLevelPackages": [
+            { "id": "Pulumi", "requestedVersion": "3.*", "resolvedVersion": "3.60.0" }
+          ]
+        },
+        {
+          "framework": "net8.0",
+          "topLevelPackages": [
+            { "id": "Pulumi", "requestedVersion": "3.*", "resolvedVersion": "3.60.0" },
+            { "id": "Microsoft.NET.ILLink.Tasks", "requestedVersion": "[8.0.1, )", "resolvedVersion": "8.0.1",
+              "autoReferenced": true }
+          ],
+          "transitivePackages": [
+            { "id": "Google.Protobuf", "resolvedVersion": "3.10.0" }
+          ]
+        }
+      ]
+    },
+    {
+      "path": "/src/Tests/Tests.csproj",
+      "frameworks": [
+        {
+          "framework": "net8.0",
+          "topLevelPackages": [
+            { "id": "Microsoft.NET.ILLink.Tasks", "requestedVersion": "[8.0.1, )", "resolvedVersion": "8.0.1",
+              "autoReferenced": "true" }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+	list, err := parsePackageListJSON([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, &packageList{
+		Projects: []projectPackages{{
+			Path: "/src/Infra/Infra.csproj",
+			Frameworks: []frameworkPackages{
+				{
+					Framework: "net6.0",
+					Packages: []packageReference{
+						{ID: "Pulumi", RequestedVersion: "3.*", ResolvedVersion: "3.60.0"},
+					},
+				},
+				{
+					Framework: "net8.0",
+					Packages: []packageReference{
+						{ID: "Pulumi", RequestedVersion: "3.*", ResolvedVersion: "3.60.0"},
+						{
+							ID: "Microsoft.NET.ILLink.Tasks", RequestedVersion: "[8.0.1, )", ResolvedVersion: "8.0.1",
+							AutoReferenced: true,
+						},
+						{ID: "Google.Protobuf", ResolvedVersion: "3.10.0", Transitive: true},
+					},
+				},
+			},
+		},
+			{
+				Path: "/src/Tests/Tests.csproj",
+				Frameworks: []frameworkPackages{{
+					Framework: "net8.0",
+					Packages: []packageReference{{
+						ID: "Microsoft.NET.ILLink.Tasks", RequestedVersion: "[8.0.1, )", ResolvedVersion: "8.0.1",
+						AutoReferenced: true,
+					}},
+				}},
+			}},
+	}, list)
+}
+
+func TestParsePackageListJSONProblems(t *testing.T) {
+	t.Parallel()
+
+	output := `{
+  "version": 1,
+  "parameters": "--include-transitive",
+  "problems": [
+    {
+      "project": "/src/Infra/Infra.csproj",
+      "level": "error",
+      "text": "No assets file was found for ` + "`/src/Infra/Infra.csproj`" + `. Please run restore first."
+    }
+  ],
+  "projects": [
+    { "path": "/src/Infra/Infra.csproj" }
+  ]
+}`
+
+	_, err := parsePackageListJSON([]byte(output))
+	assert.ErrorContains(t, err, "/src/Infra/Infra.csproj: No assets file was found")
+
+	_, err = parsePackageListJSON([]byte(`{"version": 2, "projects": []}`))
+	assert.ErrorContains(t, err, "unsupported 'dotnet list package' JSON output version: 2")
+}
+
+func TestParsePackageListText(t *testing.T) {
+	t.Parallel()
+
+	output := "Project 'Infra' has the following package references\r\n" +
+		"   [net6.0]: No packages were found for this framework.\r\n" +
+		"   [net8.0]:\r\n" +
+		"   Top-level Package                     Requested   Resolved\r\n" +
+		"   > Microsoft.NET.ILLink.Tasks    (A)   [8.0.1, )   8.0.1\r\n" +
+		"   > Pulumi                              3.*         3.60.0\r\n" +
+		"\r\n" +
+		"   Transitive Package      Resolved\r\n" +
+		"   > Google.Protobuf       3.10.0\r\n" +
+		"\r\n" +
+		"Das Projekt „Components“ enthält die folgenden Paketverweise:\n" +
+		"   [net8.0]:\n" +
+		"   Paket oberster Ebene    Angefordert   Aufgelöst\n" +
+		"   > Pulumi.Aws            6.0.0         6.0.0\n"
+
+	list, err := parsePackageListText(output)
+	require.NoError(t, err)
+	assert.Equal(t, &packageList{
+		Projects: []projectPackages{
+			{
+				Path: "Infra",
+				Frameworks: []frameworkPackages{
+					{Framework: "net6.0"},
+					{
+						Framework: "net8.0",
+						Packages: []packageReference{
+							{
+								ID: "Microsoft.NET.ILLink.Tasks", RequestedVersion: "[8.0.1, )", ResolvedVersion: "8.0.1",
+								AutoReferenced: true,
+							},
+							{ID: "Pulumi", RequestedVersion: "3.*", ResolvedVersion: "3.60.0"},
+							{ID: "Google.Protobuf", ResolvedVersion: "3.10.0", Transitive: true},
+						},
+					},
+				},
+			},
+			{
+				Path: "Components",
+				Frameworks: []frameworkPackages{
+					{
+						Framework: "net8.0",
+						Packages: []packageReference{
+							{ID: "Pulumi.Aws", RequestedVersion: "6.0.0", ResolvedVersion: "6.0.0"},
+						},
+					},
+				},
+			},
+		},
+	}, list)
+}
+
+func TestParsePackageListTextErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := parsePackageListText("> Pulumi 3.60.0 3.60.0\n")
+	assert.ErrorContains(t, err, "listed before any target framework")
+
+	_, err = parsePackageListText("[net8.0]:\n> Pulumi\n")
+	assert.ErrorContains(t, err, `could not parse "> Pulumi"`)
+
+	_, err = parsePackageListText("[net8.0]:\n> Pulumi 1 2 3\n")
+	assert.ErrorContains(t, err, `could not parse "> Pulumi 1 2 3"`)
+}
+
+func TestProgramDependencies(t *testing.T) {
+	t.Parallel()
+
+	list := &packageList{
+		Projects: []projectPackages{
+			{
+				Path: "/program/Infra.csproj",
+				Frameworks: []frameworkPackages{
+					{
+						Framework: "net6.0",
+						Packages: []packageReference{
+							{ID: "Pulumi", RequestedVersion: "3.*", ResolvedVersion: "3.60.0"},
+							{ID: "Pulumi.Aws", RequestedVersion: "[6.0.0, 7.0.0)", ResolvedVersion: "6.0.0"},
+							{ID: "Google.Protobuf", ResolvedVersion: "3.10.0", Transitive: true},
+						},
+					},
+					{
+						Framework: "net8.0",
+						Packages: []packageReference{
+							{ID: "Pulumi", RequestedVersion: "3.*", ResolvedVersion: "3.60.0"},
+							{ID: "Pulumi.Aws", RequestedVersion: "[6.0.0, 7.0.0)", ResolvedVersion: "6.0.0"},
+							{ID: "Google.Protobuf", ResolvedVersion: "3.12.0", Transitive: true},
+						},
+					},
+				},
+			},
+			{
+				Path: "/program/Components/Components.csproj",
+				Frameworks: []frameworkPackages{
+					{
+						Framework: "net8.0",
+						Packages: []packageReference{
+							{ID: "Pulumi", RequestedVersion: "3.60.0", ResolvedVersion: "3.60.0"},
+							{ID: "Google.Protobuf", ResolvedVersion: "3.12.0", Transitive: true},
+							{ID: "pulumi.aws", ResolvedVersion: "6.0.0", Transitive: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Names are only ever the package ids and versions the resolved ones, once for each version across the projects
+	// and frameworks.
+	assert.Equal(t, []*pulumirpc.DependencyInfo{
+		{Name: "Pulumi", Version: "3.60.0"},
+		{Name: "Pulumi.Aws", Version: "6.0.0"},
+		{Name: "Google.Protobuf", Version: "3.10.0"},
+		{Name: "Google.Protobuf", Version: "3.12.0"},
+	}, programDependencies(list))
+
+	assert.Equal(t, []*pulumirpc.DependencyInfo{
+		{Name: "Pulumi", Version: "3.60.0"},
+		{Name: "Google.Protobuf", Version: "3.12.0"},
+		{Name: "pulumi.aws", Version: "6.0.0"},
+	}, programDependencies(&packageList{Projects: list.Projects[1:]}))
+}
@@ -0,0 +1,111 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// terminationGracePeriodEnvVar overrides how long dotnet processes get to exit after being interrupted before they're
+// killed, as a Go duration such as `30s`.
+const terminationGracePeriodEnvVar = "PULUMI_DOTNET_TERMINATION_GRACE_PERIOD"
+
+const defaultTerminationGracePeriod = 10 * time.Second
+
+// hostShutdown is cancelled when the language host itself is asked to stop, see stopProcessGroups.
+var hostShutdown = struct {
+	sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	signal os.Signal
+}{}
+
+func init() {
+	hostShutdown.ctx, hostShutdown.cancel = context.WithCancel(context.Background())
+}
+
+// stopProcessGroups interrupts every dotnet process the language host is running with sig, because the host received
+// it.  The gRPC server stops gracefully, waiting for in-flight requests, so their contexts aren't cancelled.
+func stopProcessGroups(sig os.Signal) {
+	hostShutdown.Lock()
+	hostShutdown.signal = sig
+	hostShutdown.Unlock()
+	hostShutdown.cancel()
+}
+
+// terminationGracePeriod returns how long to wait for interrupted processes to exit before killing them.
+func terminationGracePeriod() time.Duration {
+	value := os.Getenv(terminationGracePeriodEnvVar)
+	if value == "" {
+		return defaultTerminationGracePeriod
+	}
+	gracePeriod, err := time.ParseDuration(value)
+	if err != nil || gracePeriod < 0 {
+		logging.Warningf("ignoring invalid %s=%q, using %v", terminationGracePeriodEnvVar, value,
+			defaultTerminationGracePeriod)
+		return defaultTerminationGracePeriod
+	}
+	return gracePeriod
+}
+
+// dotnetCommand is like exec.CommandContext, but runs the command in its own process group so that when ctx is
+// cancelled, or the language host is stopped, the whole process tree is stopped: not just `dotnet`, but also the
+// program started by `dotnet run` and any MSBuild worker nodes.  The processes are first interrupted so that the
+// program can finish in-flight resource registrations, and then killed if they're still running after the grace
+// period.
+//
+// Every command is traced, with a span of its own that the command's own spans are children of through
+// `TRACEPARENT`.  Commands that replace the environment should use setCommandEnv to keep that.  MSBuild commands also
+// write a binary log if ctx asks for them, see withBinlogs.
+//
+// The returned function must be called once the command has finished.
+func dotnetCommand(ctx context.Context, name string, args ...string) (*exec.Cmd, func()) {
+	ctx, span, args := startCommandSpan(ctx, name, args)
+	binlog := binlogPath(ctx, args)
+	if binlog != "" {
+		args = append(args[:len(args):len(args)], "-bl:"+binlog)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(hostShutdown.ctx, cancel)
+
+	cmd := exec.CommandContext(ctx, name, args...) //nolint:gosec // intentionally running dynamic program name.
+	if env := traceContextEnv(ctx); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	setProcessGroup(cmd)
+	gracePeriod := terminationGracePeriod()
+	cmd.Cancel = func() error {
+		hostShutdown.Lock()
+		sig := hostShutdown.signal
+		hostShutdown.Unlock()
+		if sig == nil {
+			sig = os.Interrupt
+		}
+		return terminateProcessGroup(cmd.Process, sig, gracePeriod)
+	}
+
+	return cmd, func() {
+		stop()
+		cancel()
+		span.end(cmd)
+		reportBinlog(ctx, binlog)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcessGroup sends sig to the process group led by process, and kills the group if any of it is still
+// running after gracePeriod.
+func terminateProcessGroup(process *os.Process, sig os.Signal, gracePeriod time.Duration) error {
+	pgid := process.Pid
+	logging.V(3).Infof("sending %v to process group %d, it will be killed if still running in %v",
+		sig, pgid, gracePeriod)
+	if err := syscall.Kill(-pgid, sig.(syscall.Signal)); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+
+	go func() {
+		deadline := time.Now().Add(gracePeriod)
+		for time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			// Signal 0 checks whether any process in the group is still alive.
+			if errors.Is(syscall.Kill(-pgid, 0), syscall.ESRCH) {
+				logging.V(3).Infof("process group %d exited after %v", pgid, sig)
+				return
+			}
+		}
+		logging.Warningf("process group %d still running %v after %v, killing it", pgid, gracePeriod, sig)
+		if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+			logging.Warningf("could not kill process group %d: %v", pgid, err)
+		}
+	}()
+	return nil
+}
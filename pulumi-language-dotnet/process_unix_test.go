@@ -0,0 +1,131 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+//nolint:paralleltest // sets environment variables
+func TestDotnetCommandTerminatesProcessGroup(t *testing.T) {
+	t.Setenv(terminationGracePeriodEnvVar, "500ms")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	// Background jobs of a non-interactive shell ignore SIGINT, like a process that doesn't shut down in time.
+	cmd, release := dotnetCommand(ctx, "sh", "-c", "sleep 60 & echo $!; wait")
+	defer release()
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	grandchild, err := strconv.Atoi(strings.TrimSpace(line))
+	require.NoError(t, err)
+
+	cancel()
+	assert.Error(t, cmd.Wait())
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(grandchild, 0) == syscall.ESRCH
+	}, 5*time.Second, 50*time.Millisecond, "the grandchild should have been killed with its group")
+}
+
+//nolint:paralleltest // sets environment variables
+func TestTerminationGracePeriod(t *testing.T) {
+	t.Setenv(terminationGracePeriodEnvVar, "")
+	assert.Equal(t, defaultTerminationGracePeriod, terminationGracePeriod())
+	t.Setenv(terminationGracePeriodEnvVar, "1m")
+	assert.Equal(t, time.Minute, terminationGracePeriod())
+	t.Setenv(terminationGracePeriodEnvVar, "soon")
+	assert.Equal(t, defaultTerminationGracePeriod, terminationGracePeriod())
+}
+
+//nolint:paralleltest // sets the global tracer provider
+func TestDotnetCommandSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	// Stands in for `dotnet build`, writing the performance summary it's asked for and failing.
+	dir := t.TempDir()
+	fakeDotnet := filepath.Join(dir, "dotnet")
+	require.NoError(t, os.WriteFile(fakeDotnet, []byte(`#!/bin/sh
+for arg; do
+  case "$arg" in -flp9:LogFile=*) log=${arg#-flp9:LogFile=}; log=${log%%;*};; esac
+done
+cat "$SUMMARY" > "$log"
+echo "$TRACEPARENT"
+exit 3
+`), 0o700))
+	summary := filepath.Join(dir, "summary.log")
+	require.NoError(t, os.WriteFile(summary, []byte(testMSBuildPerformanceSummary), 0o600))
+	t.Setenv("SUMMARY", summary)
+
+	ctx, parent := provider.Tracer("test").Start(t.Context(), "GetRequiredPackages")
+	cmd, release := dotnetCommand(withAttempt(ctx, 2), fakeDotnet, "build", "Infra.csproj")
+	out, err := cmd.Output()
+	release()
+	parent.End()
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	var command sdktrace.ReadOnlySpan
+	var msbuild []string
+	for _, span := range spans {
+		switch {
+		case span.Name() == "dotnet build":
+			command = span
+		case strings.HasPrefix(span.Name(), "msbuild "):
+			msbuild = append(msbuild, span.Name())
+		}
+	}
+	require.NotNil(t, command)
+	assert.Equal(t, parent.SpanContext().SpanID(), command.Parent().SpanID())
+	assert.Equal(t, otelcodes.Error, command.Status().Code)
+	attributes := attribute.NewSet(command.Attributes()...)
+	exitCode, _ := attributes.Value("process.exit.code")
+	assert.Equal(t, int64(3), exitCode.AsInt64())
+	attempt, _ := attributes.Value("pulumi.dotnet.attempt")
+	assert.Equal(t, int64(2), attempt.AsInt64())
+
+	// The command's own spans are children of its span.
+	assert.Equal(t, "00-"+command.SpanContext().TraceID().String()+"-"+command.SpanContext().SpanID().String()+"-01",
+		strings.TrimSpace(string(out)))
+	assert.Contains(t, msbuild, "msbuild target CoreCompile")
+	assert.Contains(t, msbuild, "msbuild task Csc")
+	assert.Contains(t, msbuild, "msbuild project /src/Infra/Infra.csproj")
+}
@@ -0,0 +1,40 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills process and all its descendants.  Console programs can't be sent an interrupt without
+// sharing our console, so there's no graceful option on Windows.
+func terminateProcessGroup(process *os.Process, sig os.Signal, gracePeriod time.Duration) error {
+	logging.V(3).Infof("killing process tree %d", process.Pid)
+	//nolint:gosec // the pid is our own child's
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid)).Run(); err != nil {
+		logging.V(3).Infof("taskkill failed, killing process %d: %v", process.Pid, err)
+		return process.Kill()
+	}
+	return nil
+}
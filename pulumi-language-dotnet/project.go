@@ -0,0 +1,325 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// buildConfigurations are the configurations every .NET project has.
+var buildConfigurations = []string{"Debug", "Release"}
+
+var projectReferenceRegexp = regexp.MustCompile(`<ProjectReference\s+Include\s*=\s*"([^"]+)"`)
+
+// projectReferences returns the paths of the projects projectFile references.
+func projectReferences(projectFile string) ([]string, error) {
+	if isFileBasedProgram(projectFile) {
+		return fileProgramReferences(projectFile)
+	}
+	data, err := os.ReadFile(projectFile)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, m := range projectReferenceRegexp.FindAllStringSubmatch(string(data), -1) {
+		ref := filepath.FromSlash(strings.ReplaceAll(m[1], `\`, "/"))
+		if !filepath.IsAbs(ref) {
+			ref = filepath.Join(filepath.Dir(projectFile), ref)
+		}
+		refs = append(refs, filepath.Clean(ref))
+	}
+	return refs, nil
+}
+
+// projectReferenceDependencies walks the graph of projects referenced by project, and returns the plugins needed by
+// those that are Pulumi SDKs, identified by the `pulumi-plugin.json` or `version.txt` next to their project file.
+// These are typically SDKs generated locally by `pulumi package add`.
+func projectReferenceDependencies(project string) ([]*pulumirpc.PackageDependency, error) {
+	projectFile, err := findProjectFile(project)
+	if err != nil {
+		// Without a single project file there's no graph to walk, packages are all we can go on.
+		logging.V(5).Infof("GetRequiredPackages: not looking for project references: %v", err)
+		return nil, nil
+	}
+
+	var result []*pulumirpc.PackageDependency
+	visited := map[string]bool{projectFile: true}
+	queue := []string{projectFile}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		refs, err := projectReferences(current)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			if visited[ref] {
+				continue
+			}
+			visited[ref] = true
+			if !fileExists(ref) {
+				// Let the build report the missing project.
+				logging.V(5).Infof("GetRequiredPackages: referenced project %s does not exist", ref)
+				continue
+			}
+			queue = append(queue, ref)
+
+			// MSBuild versions packages 1.0.0 by default.
+			version := projectProperty(ref, "Version")
+			if version == "" {
+				version = "1.0.0"
+			}
+			plugin, plugins, err := pluginDependencies(filepath.Dir(ref), projectAssemblyName(ref), version)
+			if err != nil {
+				return nil, errors.Wrapf(err, "referenced project %s", ref)
+			}
+			if plugin != nil {
+				logging.V(5).Infof("GetRequiredPackages: %s is the SDK of plugin %s", ref, plugin.Name)
+				result = append(result, plugin)
+			}
+			result = append(result, plugins...)
+		}
+	}
+	return result, nil
+}
+
+// listProjectFiles returns the project files directly inside dir, sorted by name.
+func listProjectFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var projects []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isProjectFile(entry.Name()) {
+			projects = append(projects, entry.Name())
+		}
+	}
+	sort.Strings(projects)
+	return projects, nil
+}
+
+// projectProperty looks for an MSBuild property in the project file and the closest `Directory.Build.props` above
+// it.  Values the project sets win over imported ones.  Values that depend on other MSBuild properties can't be
+// evaluated without MSBuild and are ignored.  File-based programs set properties with `#:property` directives.
+func projectProperty(projectFile, name string) string {
+	files := []string{projectFile}
+	if isFileBasedProgram(projectFile) {
+		if value, ok := fileDirectiveProperty(projectFile, name); ok {
+			value = strings.ReplaceAll(value, "$(MSBuildProjectDirectory)", filepath.Dir(projectFile))
+			if strings.Contains(value, "$(") {
+				logging.V(5).Infof("ignoring %s in %s: it can't be evaluated without MSBuild", value, projectFile)
+				return ""
+			}
+			return value
+		}
+		files = nil
+	}
+	for d := filepath.Dir(projectFile); ; d = filepath.Dir(d) {
+		if path := filepath.Join(d, "Directory.Build.props"); fileExists(path) {
+			files = append(files, path)
+			// MSBuild only imports the closest one, which has to import any further ones explicitly.
+			break
+		}
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+
+	re := regexp.MustCompile(`<` + name + `(?:\s[^>]*)?>\s*([^<]*?)\s*</` + name + `>`)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		matches := re.FindAllStringSubmatch(string(data), -1)
+		if len(matches) == 0 {
+			continue
+		}
+		// The last definition in a file wins.
+		value := matches[len(matches)-1][1]
+		value = strings.ReplaceAll(value, "$(MSBuildProjectDirectory)", filepath.Dir(projectFile))
+		value = strings.ReplaceAll(value, "$(MSBuildThisFileDirectory)", filepath.Dir(path)+string(filepath.Separator))
+		if strings.Contains(value, "$(") {
+			logging.V(5).Infof("ignoring %s in %s: it can't be evaluated without MSBuild", value, path)
+			return ""
+		}
+		return value
+	}
+	return ""
+}
+
+// projectTargetFrameworks returns the target frameworks of a project, from `TargetFrameworks` or `TargetFramework`.
+func projectTargetFrameworks(projectFile string) []string {
+	value := projectProperty(projectFile, "TargetFrameworks")
+	if value == "" {
+		value = projectProperty(projectFile, "TargetFramework")
+	}
+	var frameworks []string
+	for _, framework := range strings.Split(value, ";") {
+		if framework = strings.TrimSpace(framework); framework != "" {
+			frameworks = append(frameworks, framework)
+		}
+	}
+	return frameworks
+}
+
+// projectAssemblyName returns the name of the assembly a project builds.
+func projectAssemblyName(projectFile string) string {
+	if name := projectProperty(projectFile, "AssemblyName"); name != "" {
+		return name
+	}
+	return strings.TrimSuffix(filepath.Base(projectFile), filepath.Ext(projectFile))
+}
+
+// runtimeOptionsPrompts returns the questions to ask about the program in programDirectory, given the options
+// answered so far.  The engine asks again with the answers until there's nothing left to ask, so questions that
+// depend on earlier answers are only returned once those are known.  Every question has a default so that
+// `pulumi new --yes` works.
+func runtimeOptionsPrompts(
+	programDirectory, entryPoint string, options map[string]interface{},
+) ([]*pulumirpc.RuntimeOptionPrompt, error) {
+	if _, ok := options["binary"]; ok {
+		return nil, nil
+	}
+	// Don't use parseOptions, the SDK doesn't have to be installed yet to answer these questions.
+	var opts dotnetOptions
+	var err error
+	if opts.project, err = stringOption(options, "project"); err != nil {
+		return nil, err
+	}
+	if opts.framework, err = stringOption(options, "framework"); err != nil {
+		return nil, err
+	}
+	if opts.configuration, err = stringOption(options, "configuration"); err != nil {
+		return nil, err
+	}
+
+	// Which project, when there's a choice.
+	if opts.project == "" && (entryPoint == "" || entryPoint == ".") {
+		projects, err := listProjectFiles(programDirectory)
+		if err != nil {
+			return nil, err
+		}
+		if len(projects) > 1 {
+			defaultProject := projects[0]
+			for _, project := range projects {
+				if referencesPulumi(filepath.Join(programDirectory, project)) {
+					defaultProject = project
+					break
+				}
+			}
+			return []*pulumirpc.RuntimeOptionPrompt{
+				stringPrompt("project", "The project to build and run", projects, nil, defaultProject),
+			}, nil
+		}
+	}
+
+	projectFile, err := opts.projectPath(programDirectory, entryPoint)
+	var ambiguous *ambiguousProjectError
+	if opts.project == "" && (entryPoint == "" || entryPoint == ".") && errors.As(err, &ambiguous) {
+		// The program is one of the projects of the solution.
+		projects := make([]string, len(ambiguous.candidates))
+		for i, candidate := range ambiguous.candidates {
+			if projects[i], err = filepath.Rel(programDirectory, filepath.Join(ambiguous.dir, candidate)); err != nil {
+				return nil, err
+			}
+			projects[i] = filepath.ToSlash(projects[i])
+		}
+		return []*pulumirpc.RuntimeOptionPrompt{
+			stringPrompt("project", "The project to build and run", projects, nil, projects[0]),
+		}, nil
+	} else if err != nil {
+		// There's nothing to build, so nothing to ask about it.
+		logging.V(5).Infof("RuntimeOptionsPrompts: %v", err)
+		return nil, nil
+	}
+
+	// How to build it.  Whether to run a prebuilt binary isn't asked, there's never one to choose when a project is
+	// created, which is when the engine asks.
+	var prompts []*pulumirpc.RuntimeOptionPrompt
+	frameworks := projectTargetFrameworks(projectFile)
+	if _, ok := options["framework"]; !ok && len(frameworks) > 1 {
+		prompts = append(prompts,
+			stringPrompt("framework", "The target framework to build", frameworks, nil, frameworks[0]))
+	}
+	if _, ok := options["configuration"]; !ok {
+		// Debug is the default anyway, so only projects with configurations of their own need to pick one.
+		if configurations := projectConfigurations(projectFile); len(configurations) > 0 {
+			defaultConfiguration := configurations[0]
+			if slices.Contains(configurations, "Debug") {
+				defaultConfiguration = "Debug"
+			}
+			prompts = append(prompts, stringPrompt(
+				"configuration", "The build configuration", configurations, nil, defaultConfiguration))
+		}
+	}
+	return prompts, nil
+}
+
+// projectConfigurations returns the build configurations a project declares in `Configurations`, or nil if it only
+// has the ones every project has.
+func projectConfigurations(projectFile string) []string {
+	var configurations []string
+	custom := false
+	for _, configuration := range strings.Split(projectProperty(projectFile, "Configurations"), ";") {
+		if configuration = strings.TrimSpace(configuration); configuration != "" {
+			configurations = append(configurations, configuration)
+			custom = custom || !slices.Contains(buildConfigurations, configuration)
+		}
+	}
+	if !custom {
+		return nil
+	}
+	return configurations
+}
+
+func stringPrompt(
+	key, description string, choices, displayNames []string, defaultChoice string,
+) *pulumirpc.RuntimeOptionPrompt {
+	prompt := &pulumirpc.RuntimeOptionPrompt{
+		Key:         key,
+		Description: description,
+		PromptType:  pulumirpc.RuntimeOptionPrompt_STRING,
+		Default: &pulumirpc.RuntimeOptionPrompt_RuntimeOptionValue{
+			PromptType:  pulumirpc.RuntimeOptionPrompt_STRING,
+			StringValue: defaultChoice,
+		},
+	}
+	for i, choice := range choices {
+		value := &pulumirpc.RuntimeOptionPrompt_RuntimeOptionValue{
+			PromptType:  pulumirpc.RuntimeOptionPrompt_STRING,
+			StringValue: choice,
+			DisplayName: choice,
+		}
+		if displayNames != nil {
+			value.DisplayName = displayNames[i]
+		}
+		if choice == defaultChoice {
+			prompt.Default.DisplayName = value.DisplayName
+		}
+		prompt.Choices = append(prompt.Choices, value)
+	}
+	return prompt
+}
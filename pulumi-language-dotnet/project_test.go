@@ -0,0 +1,239 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectTargetFrameworks(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	projectFile := filepath.Join(root, "src", "Infra.csproj")
+	require.NoError(t, os.MkdirAll(filepath.Dir(projectFile), 0o700))
+	require.NoError(t, os.WriteFile(projectFile, []byte(`<Project Sdk="Microsoft.NET.Sdk" />`), 0o600))
+	assert.Empty(t, projectTargetFrameworks(projectFile))
+	assert.Equal(t, "Infra", projectAssemblyName(projectFile))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Directory.Build.props"), []byte(`<Project>
+  <PropertyGroup>
+    <TargetFramework>net6.0</TargetFramework>
+  </PropertyGroup>
+</Project>`), 0o600))
+	assert.Equal(t, []string{"net6.0"}, projectTargetFrameworks(projectFile))
+
+	require.NoError(t, os.WriteFile(projectFile, []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup Condition="'$(OS)' != 'Windows_NT'">
+    <TargetFrameworks> net8.0; net9.0 </TargetFrameworks>
+    <AssemblyName>Company.Infra</AssemblyName>
+  </PropertyGroup>
+</Project>`), 0o600))
+	assert.Equal(t, []string{"net8.0", "net9.0"}, projectTargetFrameworks(projectFile))
+	assert.Equal(t, "Company.Infra", projectAssemblyName(projectFile))
+}
+
+func TestRuntimeOptionsPrompts(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Infra.csproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net8.0;net9.0</TargetFrameworks>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Pulumi" Version="3.*" />
+  </ItemGroup>
+</Project>`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Analyzers.csproj"), []byte("<Project />"), 0o600))
+
+	// Answer every question with its default, the way `pulumi new --yes` does.
+	options := map[string]interface{}{}
+	var keys []string
+	for {
+		prompts, err := runtimeOptionsPrompts(dir, ".", options)
+		require.NoError(t, err)
+		if len(prompts) == 0 {
+			break
+		}
+		for _, prompt := range prompts {
+			require.NotNil(t, prompt.Default, prompt.Key)
+			assert.Equal(t, pulumirpc.RuntimeOptionPrompt_STRING, prompt.PromptType)
+			keys = append(keys, prompt.Key)
+			options[prompt.Key] = prompt.Default.StringValue
+		}
+		require.Less(t, len(keys), 10, "prompts never finished")
+	}
+
+	// Debug is the default configuration, so there's nothing to ask about it.
+	assert.Equal(t, []string{"project", "framework"}, keys)
+	assert.Equal(t, map[string]interface{}{
+		"project":   "Infra.csproj",
+		"framework": "net8.0",
+	}, options)
+
+	// Nor is a binary offered once one has been built.
+	dll := filepath.Join(dir, "bin", "Debug", "net8.0", "Infra.dll")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dll), 0o700))
+	require.NoError(t, os.WriteFile(dll, nil, 0o600))
+	prompts, err := runtimeOptionsPrompts(dir, ".", options)
+	require.NoError(t, err)
+	assert.Empty(t, prompts)
+
+	// Only the configuration is asked about for a single project with a single framework and configurations of its
+	// own.
+	single := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(single, "Infra.fsproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+    <Configurations>Debug;Release;Staging</Configurations>
+  </PropertyGroup>
+</Project>`), 0o600))
+	prompts, err = runtimeOptionsPrompts(single, ".", map[string]interface{}{})
+	require.NoError(t, err)
+	require.Len(t, prompts, 1)
+	assert.Equal(t, "configuration", prompts[0].Key)
+	assert.Equal(t, "Debug", prompts[0].Default.StringValue)
+	require.Len(t, prompts[0].Choices, 3)
+	assert.Equal(t, "Staging", prompts[0].Choices[2].StringValue)
+
+	_, err = runtimeOptionsPrompts(single, ".", map[string]interface{}{"framework": 8})
+	assert.ErrorContains(t, err, "framework option must be a string")
+}
+
+func TestParseOptionsBuildSelection(t *testing.T) {
+	t.Parallel()
+
+	program := t.TempDir()
+	for _, name := range []string{"Infra.csproj", "Other.csproj"} {
+		require.NoError(t, os.WriteFile(filepath.Join(program, name), []byte("<Project />"), 0o600))
+	}
+	opts, err := parseOptions("", map[string]interface{}{
+		"use-executor":  "dotnet",
+		"project":       "Infra.csproj",
+		"framework":     "net8.0",
+		"configuration": "Release",
+	})
+	require.NoError(t, err)
+	project, err := opts.projectPath(program, ".")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(program, "Infra.csproj"), project)
+	project, err = opts.projectPath(program, "Other.csproj")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(program, "Other.csproj"), project)
+	assert.Equal(t, []string{
+		"build", "-nologo", "Infra.csproj", "--configuration", "Release", "--framework", "net8.0",
+	}, opts.buildArgs("Infra.csproj"))
+	assert.Equal(t, []string{
+		"run", "--no-build", "--project", "Infra.csproj", "--configuration", "Release", "--framework", "net8.0",
+	}, opts.runArgs("Infra.csproj", true))
+	assert.Equal(t, []string{
+		"run", "Program.cs", "--configuration", "Release", "--framework", "net8.0",
+	}, opts.runArgs("Program.cs", false))
+
+	_, err = parseOptions("", map[string]interface{}{"use-executor": "dotnet", "configuration": true})
+	assert.ErrorContains(t, err, "configuration option must be a string")
+
+	opts, err = parseOptions("", map[string]interface{}{
+		"use-executor": "dotnet",
+		"runtime":      "linux-x64",
+		"msbuildProperties": map[string]interface{}{
+			"TreatWarningsAsErrors": true,
+			"WarningLevel":          float64(4),
+			"DefineConstants":       "STAGING;TRACE",
+		},
+		"restoreSources": []interface{}{"https://api.nuget.org/v3/index.json", "../packages"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--runtime", "linux-x64",
+		"--property:DefineConstants=STAGING%3BTRACE",
+		"--property:RestoreSources=https://api.nuget.org/v3/index.json%3B../packages",
+		"--property:TreatWarningsAsErrors=true",
+		"--property:WarningLevel=4",
+	}, opts.msbuildArgs())
+
+	_, err = parseOptions("", map[string]interface{}{"use-executor": "dotnet", "restoreSources": "../packages"})
+	assert.ErrorContains(t, err, "restoreSources option must be a list of strings")
+	_, err = parseOptions("", map[string]interface{}{
+		"use-executor":      "dotnet",
+		"msbuildProperties": map[string]interface{}{"Nested": map[string]interface{}{}},
+	})
+	assert.ErrorContains(t, err, "msbuildProperties option Nested must be a string, number or bool")
+}
+
+func TestProjectReferenceDependencies(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFile := func(path, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(contents), 0o600))
+	}
+
+	writeFile("program/Infra.csproj", `<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <ProjectReference Include="..\sdks\random\Pulumi.Random.csproj" />
+    <ProjectReference Include="../common/Common.csproj" />
+    <ProjectReference Include="../missing/Missing.csproj" />
+  </ItemGroup>
+</Project>`)
+	writeFile("sdks/random/Pulumi.Random.csproj", `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <Version>4.16.0</Version>
+  </PropertyGroup>
+</Project>`)
+	writeFile("sdks/random/pulumi-plugin.json", `{
+  "resource": true,
+  "name": "terraform-provider",
+  "version": "0.3.0",
+  "parameterization": {"name": "random", "version": "4.16.0", "value": "eyJyZW1vdGUiOnt9fQ=="}
+}`)
+	// Projects that aren't SDKs are walked through.
+	writeFile("common/Common.csproj", `<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <ProjectReference Include="../sdks/tls/Pulumi.Tls.csproj" />
+    <ProjectReference Include="../program/Infra.csproj" />
+  </ItemGroup>
+</Project>`)
+	writeFile("sdks/tls/Pulumi.Tls.csproj", `<Project Sdk="Microsoft.NET.Sdk" />`)
+	writeFile("sdks/tls/version.txt", "5.0.0")
+
+	actual, err := projectReferenceDependencies(filepath.Join(root, "program"))
+	require.NoError(t, err)
+	assert.Equal(t, []*pulumirpc.PackageDependency{
+		{
+			Kind:    "resource",
+			Name:    "terraform-provider",
+			Version: "0.3.0",
+			Parameterization: &pulumirpc.PackageParameterization{
+				Name:    "random",
+				Version: "4.16.0",
+				Value:   []byte(`{"remote":{}}`),
+			},
+		},
+		{Kind: "resource", Name: "tls", Version: "v5.0.0"},
+	}, actual)
+
+	writeFile("sdks/tls/version.txt", "five")
+	_, err = projectReferenceDependencies(filepath.Join(root, "program"))
+	assert.ErrorContains(t, err, "Pulumi.Tls.csproj")
+}
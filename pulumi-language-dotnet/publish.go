@@ -0,0 +1,137 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// The ways a program can be published ahead of running it, chosen with the `publish` runtime option.
+const (
+	// publishReadyToRun publishes a framework-dependent assembly precompiled for the current platform.
+	publishReadyToRun = "readytorun"
+	// publishSelfContained publishes a precompiled executable that includes the .NET runtime.
+	publishSelfContained = "selfcontained"
+	// publishAOT publishes a native executable with Native AOT.
+	publishAOT = "aot"
+)
+
+// publishCacheFile records the last publish of a project, like buildCacheFile does for builds.
+const publishCacheFile = "pulumi-publish-cache.json"
+
+// publishArgs returns the arguments to publish project into outputDir.
+func (opts dotnetOptions) publishArgs(project, outputDir string) []string {
+	if opts.runtime == "" {
+		// All the publish modes are platform specific.
+		opts.runtime = currentRuntimeIdentifier()
+	}
+	args := []string{"publish", "-nologo", project, "--output", outputDir}
+	switch opts.publish {
+	case publishReadyToRun:
+		args = append(args, "--self-contained", "false", "--property:PublishReadyToRun=true")
+	case publishSelfContained:
+		args = append(args, "--self-contained", "true", "--property:PublishReadyToRun=true")
+	case publishAOT:
+		args = append(args, "--property:PublishAot=true")
+	}
+	args = append(args, opts.msbuildArgs()...)
+	return append(args, opts.nodeReuseArgs()...)
+}
+
+// publishProgram publishes project with `dotnet publish` unless it was already published from the same sources, and
+// returns the path of the published assembly or executable.
+func publishProgram(
+	ctx context.Context, opts dotnetOptions, project, programDirectory string, stdout, stderr io.Writer,
+) (string, error) {
+	projectFile, err := findProjectFile(project)
+	if err != nil {
+		return "", err
+	}
+	projectDir := filepath.Dir(projectFile)
+	runtimeIdentifier := opts.runtime
+	if runtimeIdentifier == "" {
+		runtimeIdentifier = currentRuntimeIdentifier()
+	}
+	outputDir := filepath.Join(projectDir, "obj", "pulumi-publish", opts.publish+"-"+runtimeIdentifier)
+
+	artifact := filepath.Join(outputDir, projectAssemblyName(projectFile))
+	switch {
+	case opts.publish == publishReadyToRun:
+		artifact += ".dll"
+	case runtime.GOOS == "windows":
+		artifact += ".exe"
+	}
+
+	args := opts.publishArgs(projectFile, outputDir)
+	cache, err := newBuildCache(ctx, opts.dotnetExec, projectFile, args)
+	if err != nil {
+		logging.V(5).Infof("could not fingerprint %s, publishing unconditionally: %v", projectFile, err)
+	} else {
+		cache.path = filepath.Join(projectDir, "obj", publishCacheFile)
+		if cache.upToDate() && fileExists(artifact) {
+			logging.V(5).Infof("%s was already published from the same sources", artifact)
+			return artifact, nil
+		}
+	}
+
+	logging.V(5).Infoln("Language host launching process: ", opts.dotnetExec, strings.Join(args, " "))
+	cmd, release := dotnetCommand(ctx, opts.dotnetExec, args...)
+	defer release()
+	cmd.Dir = programDirectory
+	// AOT and self-contained publishes can take minutes, so show their progress as it happens.
+	var output bytes.Buffer
+	cmd.Stdout = io.MultiWriter(stdout, &output)
+	cmd.Stderr = io.MultiWriter(stderr, &output)
+	if err := cmd.Run(); err != nil {
+		if summary := summarizeMSBuildErrors(parseMSBuildDiagnostics(output.String())); summary != "" {
+			return "", errors.Errorf("failed to publish %s:\n%s", projectFile, summary)
+		}
+		return "", errors.Wrapf(err, "failed to publish %s", projectFile)
+	}
+
+	if cache != nil {
+		if err := cache.record(output.String()); err != nil {
+			logging.V(5).Infof("could not record the publish of %s: %v", projectFile, err)
+		}
+	}
+	return artifact, nil
+}
+
+// currentRuntimeIdentifier returns the portable .NET runtime identifier of the platform the host is running on.
+func currentRuntimeIdentifier() string {
+	goos := runtime.GOOS
+	switch goos {
+	case "darwin":
+		goos = "osx"
+	case "windows":
+		goos = "win"
+	}
+	arch := runtime.GOARCH
+	switch arch {
+	case "amd64":
+		arch = "x64"
+	case "386":
+		arch = "x86"
+	}
+	return goos + "-" + arch
+}
@@ -0,0 +1,93 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishArgs(t *testing.T) {
+	t.Parallel()
+
+	opts := dotnetOptions{publish: publishSelfContained, configuration: "Release"}
+	assert.Equal(t, []string{
+		"publish", "-nologo", "Infra.csproj", "--output", "out",
+		"--self-contained", "true", "--property:PublishReadyToRun=true",
+		"--configuration", "Release", "--runtime", currentRuntimeIdentifier(),
+	}, opts.publishArgs("Infra.csproj", "out"))
+
+	opts = dotnetOptions{publish: publishAOT, runtime: "linux-musl-arm64"}
+	assert.Equal(t, []string{
+		"publish", "-nologo", "Infra.csproj", "--output", "out",
+		"--property:PublishAot=true", "--runtime", "linux-musl-arm64",
+	}, opts.publishArgs("Infra.csproj", "out"))
+
+	_, err := parseOptions("", map[string]interface{}{"use-executor": "dotnet", "publish": "native"})
+	assert.ErrorContains(t, err, `publish option must be one of readytorun, selfcontained or aot, not "native"`)
+}
+
+func TestPublishProgramCached(t *testing.T) {
+	t.Parallel()
+
+	programDir := t.TempDir()
+	projectFile := filepath.Join(programDir, "Infra.csproj")
+	require.NoError(t, os.WriteFile(projectFile, []byte("<Project />"), 0o600))
+
+	opts := dotnetOptions{dotnetExec: "dotnet", publish: publishReadyToRun}
+	outputDir := filepath.Join(programDir, "obj", "pulumi-publish", "readytorun-"+currentRuntimeIdentifier())
+	artifact := filepath.Join(outputDir, "Infra.dll")
+	require.NoError(t, os.MkdirAll(outputDir, 0o700))
+	require.NoError(t, os.WriteFile(artifact, nil, 0o600))
+
+	// Record a publish of the current sources, so publishing again is skipped.
+	cache, err := newBuildCache(t.Context(), "dotnet", projectFile, opts.publishArgs(projectFile, outputDir))
+	require.NoError(t, err)
+	cache.path = filepath.Join(programDir, "obj", publishCacheFile)
+	require.NoError(t, cache.record("  Infra -> "+outputDir+string(filepath.Separator)+"\n"))
+
+	actual, err := publishProgram(t.Context(), opts, programDir, programDir, io.Discard, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, artifact, actual)
+
+	// A different publish mode is a different publish, which fails for this empty project.
+	opts.publish = publishSelfContained
+	var output bytes.Buffer
+	_, err = publishProgram(t.Context(), opts, programDir, programDir, &output, &output)
+	assert.ErrorContains(t, err, "failed to publish")
+	assert.NotEmpty(t, output.String(), "the publish output should be streamed")
+}
+
+func TestCurrentRuntimeIdentifier(t *testing.T) {
+	t.Parallel()
+
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64":
+		assert.Equal(t, "linux-x64", currentRuntimeIdentifier())
+	case "darwin/arm64":
+		assert.Equal(t, "osx-arm64", currentRuntimeIdentifier())
+	case "windows/amd64":
+		assert.Equal(t, "win-x64", currentRuntimeIdentifier())
+	default:
+		t.Skipf("no expectation for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+}
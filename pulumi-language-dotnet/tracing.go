@@ -0,0 +1,242 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the language host's spans.
+const tracerName = "pulumi-language-dotnet"
+
+// msbuildSummarySpans is how many of the slowest projects, targets and tasks of a build get a span each.
+const msbuildSummarySpans = 10
+
+// msbuildCommands are the `dotnet` commands that run MSBuild, and so take MSBuild's switches for logging.
+var msbuildCommands = map[string]bool{"build": true, "msbuild": true, "pack": true, "publish": true, "restore": true}
+
+// commandSpan traces one subprocess from dotnetCommand.
+type commandSpan struct {
+	span  trace.Span
+	start time.Time
+	// The file MSBuild writes its performance summary to, if it was asked to.
+	summaryFile string
+}
+
+type attemptKey struct{}
+
+// withAttempt records in ctx which attempt at a command this is, for the spans of commands that are retried.
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// startCommandSpan starts the span of running name with args.  While the trace is being recorded, MSBuild commands
+// are also asked for a performance summary, which is why the arguments to run are returned.
+func startCommandSpan(ctx context.Context, name string, args []string) (context.Context, *commandSpan, []string) {
+	spanName := filepath.Base(name)
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		spanName += " " + args[0]
+	}
+	attributes := []attribute.KeyValue{
+		attribute.String("component", "exec.Command"),
+		attribute.String("process.executable.path", name),
+		attribute.StringSlice("process.command_args", args),
+	}
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		attributes = append(attributes, attribute.Int("pulumi.dotnet.attempt", attempt))
+	}
+	ctx, span := cmdutil.StartSpan(ctx, otel.Tracer(tracerName), spanName, trace.WithAttributes(attributes...))
+	s := &commandSpan{span: span, start: time.Now()}
+
+	if span.IsRecording() && takesMSBuildSwitches(args) {
+		f, err := os.CreateTemp("", "pulumi-dotnet-msbuild-*.log")
+		if err != nil {
+			logging.V(5).Infof("not tracing MSBuild's performance: %v", err)
+		} else {
+			f.Close()
+			s.summaryFile = f.Name()
+			args = append(args[:len(args):len(args)],
+				"-fl9", "-flp9:LogFile="+s.summaryFile+";Verbosity=quiet;PerformanceSummary")
+		}
+	}
+	return ctx, s, args
+}
+
+// end ends the span of cmd, which has finished or failed to start.
+func (s *commandSpan) end(cmd *exec.Cmd) {
+	if state := cmd.ProcessState; state != nil {
+		s.span.SetAttributes(attribute.Int("process.exit.code", state.ExitCode()), attribute.Int("process.pid", state.Pid()))
+		if !state.Success() {
+			s.span.SetStatus(otelcodes.Error, state.String())
+		}
+	} else {
+		s.span.SetStatus(otelcodes.Error, "not started")
+	}
+
+	if s.summaryFile != "" {
+		defer os.Remove(s.summaryFile)
+		timings, err := readMSBuildPerformanceSummary(s.summaryFile)
+		if err != nil {
+			logging.V(5).Infof("could not read MSBuild's performance summary: %v", err)
+		}
+		ctx := trace.ContextWithSpan(context.Background(), s.span)
+		for _, timing := range timings {
+			// MSBuild only reports the total time spent in each, not when, so they all start with the build.
+			_, span := otel.Tracer(tracerName).Start(ctx, "msbuild "+timing.kind+" "+timing.name,
+				trace.WithTimestamp(s.start),
+				trace.WithAttributes(attribute.Int("msbuild.calls", timing.calls)))
+			span.End(trace.WithTimestamp(s.start.Add(timing.duration)))
+		}
+	}
+	s.span.End()
+}
+
+// takesMSBuildSwitches returns whether the `dotnet` command args runs MSBuild and passes on its switches, which
+// commands for file-based programs don't.
+func takesMSBuildSwitches(args []string) bool {
+	if len(args) == 0 || !msbuildCommands[args[0]] {
+		return false
+	}
+	for _, arg := range args {
+		if isFileBasedProgram(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+// traceContextEnv returns the environment variables that make the spans of a subprocess children of the span in ctx:
+// `TRACEPARENT` and `TRACESTATE` from the W3C trace context, which .NET picks up.
+func traceContextEnv(ctx context.Context) []string {
+	carrier := make(propagation.MapCarrier)
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	var env []string
+	if traceparent := carrier.Get("traceparent"); traceparent != "" {
+		env = append(env, "TRACEPARENT="+traceparent)
+	}
+	if tracestate := carrier.Get("tracestate"); tracestate != "" {
+		env = append(env, "TRACESTATE="+tracestate)
+	}
+	return env
+}
+
+// setCommandEnv sets the environment of a command from dotnetCommand, keeping the trace context dotnetCommand gave
+// it.  Like for exec.Cmd, a nil env is the language host's own environment.
+func setCommandEnv(cmd *exec.Cmd, env []string) {
+	if env == nil {
+		env = os.Environ()
+	}
+	var traceEnv []string
+	for _, v := range cmd.Env {
+		if strings.HasPrefix(v, "TRACEPARENT=") || strings.HasPrefix(v, "TRACESTATE=") {
+			traceEnv = append(traceEnv, v)
+		}
+	}
+	cmd.Env = append(env[:len(env):len(env)], traceEnv...)
+}
+
+// msbuildTiming is a line of MSBuild's performance summary: how long was spent in a project, target or task.
+type msbuildTiming struct {
+	// What was timed: "evaluation", "project", "target" or "task".
+	kind     string
+	name     string
+	duration time.Duration
+	calls    int
+}
+
+var (
+	msbuildSummaryHeadings = map[string]string{
+		"Project Evaluation Performance Summary:": "evaluation",
+		"Project Performance Summary:":            "project",
+		"Target Performance Summary:":             "target",
+		"Task Performance Summary:":               "task",
+	}
+	// Matches `      829 ms  /src/Infra.csproj        7 calls`.
+	msbuildTimingRegexp = regexp.MustCompile(`^(\s*)(\d+) ms  (.+?)\s+(\d+) calls$`)
+)
+
+// readMSBuildPerformanceSummary reads the performance summary MSBuild writes at the end of a log, and returns the
+// slowest projects, targets and tasks, slowest first.  Each project also lists its own targets, which are already
+// counted in the summary of all targets and are left out.
+func readMSBuildPerformanceSummary(path string) ([]msbuildTiming, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sections := map[string][]msbuildTiming{}
+	kind, indent := "", -1
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r ")
+		if k, ok := msbuildSummaryHeadings[strings.TrimSpace(line)]; ok {
+			kind, indent = k, -1
+			continue
+		}
+		m := msbuildTimingRegexp.FindStringSubmatch(line)
+		if kind == "" || m == nil {
+			continue
+		}
+		// Nested lines are indented further than the first line of the section.
+		if width := len(m[1]) + len(m[2]); indent == -1 {
+			indent = width
+		} else if width > indent {
+			continue
+		}
+		ms, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		calls, err := strconv.Atoi(m[4])
+		if err != nil {
+			continue
+		}
+		sections[kind] = append(sections[kind], msbuildTiming{
+			kind: kind, name: m[3], duration: time.Duration(ms) * time.Millisecond, calls: calls,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var timings []msbuildTiming
+	for _, kind := range []string{"evaluation", "project", "target", "task"} {
+		section := sections[kind]
+		sort.SliceStable(section, func(i, j int) bool { return section[i].duration > section[j].duration })
+		if len(section) > msbuildSummarySpans {
+			section = section[:msbuildSummarySpans]
+		}
+		timings = append(timings, section...)
+	}
+	return timings, nil
+}
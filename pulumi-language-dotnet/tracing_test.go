@@ -0,0 +1,91 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMSBuildPerformanceSummary is the end of a log written with `-flp:PerformanceSummary`.
+const testMSBuildPerformanceSummary = `Project Evaluation Performance Summary:
+      213 ms  /src/Infra/Infra.csproj                    3 calls
+
+Project Performance Summary:
+      829 ms  /src/Infra/Infra.csproj                    7 calls
+                465 ms  Restore                                    1 calls
+                364 ms  Build                                      1 calls
+
+Target Performance Summary:
+        0 ms  BeforeResGen                               1 calls
+      350 ms  CoreCompile                                1 calls
+      465 ms  Restore                                    1 calls
+
+Task Performance Summary:
+       48 ms  Csc                                        1 calls
+      294 ms  RestoreTask                                1 calls
+
+Time Elapsed 00:00:01.13
+`
+
+func TestReadMSBuildPerformanceSummary(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "msbuild.log")
+	require.NoError(t, os.WriteFile(path, []byte(testMSBuildPerformanceSummary), 0o600))
+	timings, err := readMSBuildPerformanceSummary(path)
+	require.NoError(t, err)
+	assert.Equal(t, []msbuildTiming{
+		{kind: "evaluation", name: "/src/Infra/Infra.csproj", duration: 213 * time.Millisecond, calls: 3},
+		{kind: "project", name: "/src/Infra/Infra.csproj", duration: 829 * time.Millisecond, calls: 7},
+		{kind: "target", name: "Restore", duration: 465 * time.Millisecond, calls: 1},
+		{kind: "target", name: "CoreCompile", duration: 350 * time.Millisecond, calls: 1},
+		{kind: "target", name: "BeforeResGen", duration: 0, calls: 1},
+		{kind: "task", name: "RestoreTask", duration: 294 * time.Millisecond, calls: 1},
+		{kind: "task", name: "Csc", duration: 48 * time.Millisecond, calls: 1},
+	}, timings)
+
+	// Only the slowest are kept.
+	var many string
+	for i := 0; i < 2*msbuildSummarySpans; i++ {
+		many += "       " + strconv.Itoa(10+i) + " ms  Target" + strconv.Itoa(i) + "   1 calls\n"
+	}
+	require.NoError(t, os.WriteFile(path, []byte("Target Performance Summary:\n"+many), 0o600))
+	timings, err = readMSBuildPerformanceSummary(path)
+	require.NoError(t, err)
+	require.Len(t, timings, msbuildSummarySpans)
+	assert.Equal(t, "Target"+strconv.Itoa(2*msbuildSummarySpans-1), timings[0].name)
+}
+
+func TestSetCommandEnv(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("dotnet")
+	cmd.Env = []string{"HOME=/home/user", "TRACEPARENT=00-1-2-01", "TRACESTATE=a=b"}
+	setCommandEnv(cmd, []string{"PULUMI_STACK=dev"})
+	assert.Equal(t, []string{"PULUMI_STACK=dev", "TRACEPARENT=00-1-2-01", "TRACESTATE=a=b"}, cmd.Env)
+
+	// Without a trace context, a nil environment is still the host's own.
+	cmd = exec.Command("dotnet")
+	setCommandEnv(cmd, nil)
+	assert.Equal(t, os.Environ(), cmd.Env)
+}
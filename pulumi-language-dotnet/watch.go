@@ -0,0 +1,285 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// watchPollInterval is how often the `watch` option looks for changes to a plugin's sources.
+const watchPollInterval = 500 * time.Millisecond
+
+// providerPortEnvVar tells a restarted provider to listen on the port the engine already knows, see
+// Provider.BuildHost in the SDK.
+const providerPortEnvVar = "PULUMI_DOTNET_PROVIDER_PORT"
+
+// watchSdkVersion is the first version of the Pulumi SDK whose providers listen on the port in providerPortEnvVar,
+// checked against the changelog like configFileSdkVersion.
+var watchSdkVersion = semver.MustParse("3.113.0")
+
+// pluginWatcher runs a plugin from source for the `watch` option: whenever the sources of its project change it
+// rebuilds the plugin and restarts it, much like `dotnet watch` does for apps.  Build failures are written to the
+// plugin's stderr, and the plugin is started again once they're fixed.
+//
+// The engine only configures a provider once, so a restarted provider has lost the engine's Configure and Attach
+// calls.  That's good enough for providers that don't need configuring, others need the `pulumi` command run again.
+type pluginWatcher struct {
+	// The project whose build inputs are watched.
+	project string
+	// Builds the plugin, writing any build errors to stderr.
+	build func(ctx context.Context) error
+	// Returns an error if the plugin, once built, uses an SDK that can't be restarted.
+	checkSdk func() error
+	// Runs the plugin, with extra environment variables, until it exits or ctx is cancelled.
+	run func(ctx context.Context, env []string) error
+	// The plugin's stdout, which remembers the port a provider reports when it first starts.
+	stdout *firstLineWriter
+	stderr io.Writer
+	// How often to look for changes.
+	interval time.Duration
+}
+
+func newPluginWatcher(
+	opts dotnetOptions, project string, req *pulumirpc.RunPluginRequest, stdout, stderr io.Writer,
+) *pluginWatcher {
+	w := &pluginWatcher{
+		project:  project,
+		stdout:   &firstLineWriter{w: stdout},
+		stderr:   stderr,
+		interval: watchPollInterval,
+	}
+	w.build = func(ctx context.Context) error {
+		return buildPlugin(ctx, opts, project, req.Pwd, req.Env, stderr)
+	}
+	w.checkSdk = func() error {
+		return checkWatchSdk(project)
+	}
+	w.run = func(ctx context.Context, env []string) error {
+		args := append(opts.runArgs(project, true /*noBuild*/), "--")
+		args = append(args, req.Args...)
+		logging.V(5).Infoln("Language host launching process: ", opts.dotnetExec, " ", strings.Join(args, " "))
+
+		cmd, release := dotnetCommand(ctx, opts.dotnetExec, args...)
+		defer release()
+		cmd.Dir = req.Pwd
+		setCommandEnv(cmd, append(req.Env[:len(req.Env):len(req.Env)], env...))
+		cmd.Stdout, cmd.Stderr = w.stdout, stderr
+		return cmd.Run()
+	}
+	return w
+}
+
+// notWatchableError is returned by watch when the plugin's project can't be watched for changes, in which case it
+// should just be run.
+type notWatchableError struct {
+	project string
+	err     error
+}
+
+func (e *notWatchableError) Error() string {
+	return fmt.Sprintf("can't watch %s for changes: %v", e.project, e.err)
+}
+
+// watch builds and runs the plugin, and does so again whenever its sources change, until ctx is cancelled.
+func (w *pluginWatcher) watch(ctx context.Context) error {
+	fingerprint, err := w.fingerprint()
+	if err != nil {
+		return &notWatchableError{project: w.project, err: err}
+	}
+
+	checked := false
+	for {
+		// exited stays nil, and so is never ready, if the plugin doesn't start.
+		var exited chan error
+		var running sync.WaitGroup
+		runCtx, cancel := context.WithCancel(ctx)
+		if err := w.build(ctx); err != nil {
+			fmt.Fprintf(w.stderr, "%v, waiting for changes to %s\n", err, w.project)
+		} else {
+			if !checked {
+				// Only a build has restored the project, so this is the first time its SDK is known.
+				if err := w.checkSdk(); err != nil {
+					cancel()
+					return err
+				}
+				checked = true
+			}
+			exited = make(chan error, 1)
+			env := w.restartEnv()
+			running.Add(1)
+			go func() {
+				defer running.Done()
+				exited <- w.run(runCtx, env)
+			}()
+		}
+
+		var changed bool
+		fingerprint, changed = w.waitForChange(ctx, fingerprint, exited)
+		cancel()
+		running.Wait()
+		if !changed {
+			return nil
+		}
+		fmt.Fprintf(w.stderr, "%s changed, rebuilding and restarting the plugin; "+
+			"run the pulumi command again if it needs the engine to configure it\n", w.project)
+	}
+}
+
+// waitForChange returns the new fingerprint and true once the build inputs of the project have changed from
+// fingerprint and settled, or false when ctx is done.  If the plugin exits in the meantime, that is reported and the
+// watch goes on.
+func (w *pluginWatcher) waitForChange(ctx context.Context, fingerprint string, exited <-chan error) (string, bool) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	changed := false
+	for {
+		select {
+		case <-ctx.Done():
+			return fingerprint, false
+		case err := <-exited:
+			exited = nil
+			if err != nil {
+				fmt.Fprintf(w.stderr, "Plugin exited: %v, waiting for changes to %s\n", err, w.project)
+			} else {
+				fmt.Fprintf(w.stderr, "Plugin exited, waiting for changes to %s\n", w.project)
+			}
+		case <-ticker.C:
+			current, err := w.fingerprint()
+			if err != nil {
+				logging.V(5).Infof("could not fingerprint %s: %v", w.project, err)
+				continue
+			}
+			if current != fingerprint {
+				// Editors and checkouts write several files at once, so wait for them to finish.
+				fingerprint, changed = current, true
+			} else if changed {
+				return fingerprint, true
+			}
+		}
+	}
+}
+
+// fingerprint returns a fingerprint of the build inputs of the project, which changes whenever they do.
+func (w *pluginWatcher) fingerprint() (string, error) {
+	projectFile, err := findProjectFile(w.project)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if err := fingerprintProject(hash, projectFile, map[string]bool{}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checkWatchSdk returns an error unless the restored project uses a Pulumi SDK whose providers can be restarted on
+// the port the engine knows.
+func checkWatchSdk(project string) error {
+	var versions []string
+	if isFileBasedProgram(project) {
+		packageDirs, err := resolvePackageFolders(project)
+		if err != nil {
+			logging.V(5).Infof("finding the Pulumi SDK of %s: %v", project, err)
+		}
+		list, err := fileProgramPackages(project, packageDirs)
+		if err != nil {
+			return errors.Wrapf(err, "could not find the Pulumi SDK version of %s for the watch option", project)
+		}
+		for _, pkg := range list.Projects[0].Frameworks[0].Packages {
+			if strings.EqualFold(pkg.ID, "Pulumi") && pkg.ResolvedVersion != "" {
+				versions = append(versions, pkg.ResolvedVersion)
+			}
+		}
+	} else {
+		assets, _, err := readProjectAssets(project)
+		if err != nil {
+			return errors.Wrapf(err, "could not find the Pulumi SDK version of %s for the watch option", project)
+		}
+		for key, library := range assets.Libraries {
+			if id, version, ok := strings.Cut(key, "/"); ok && library.Type == "package" && strings.EqualFold(id, "Pulumi") {
+				versions = append(versions, version)
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return errors.Errorf("the watch option needs the Pulumi package %s or later, which %s doesn't reference",
+			watchSdkVersion, project)
+	}
+	sort.Strings(versions)
+	for _, version := range versions {
+		if v, err := semver.ParseTolerant(version); err != nil || v.LT(watchSdkVersion) {
+			return errors.Errorf("the watch option needs the Pulumi package %s or later, %s uses %s",
+				watchSdkVersion, project, strings.Join(versions, ", "))
+		}
+	}
+	return nil
+}
+
+// restartEnv returns the environment for starting the plugin again.  The engine only reads the port a provider
+// listens on once, so a restarted provider has to listen on the same one.
+func (w *pluginWatcher) restartEnv() []string {
+	line, ok := w.stdout.firstLine()
+	if !ok {
+		return nil
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return nil
+	}
+	return []string{providerPortEnvVar + "=" + strconv.Itoa(port)}
+}
+
+// firstLineWriter writes through to w, and remembers the first line written.
+type firstLineWriter struct {
+	w    io.Writer
+	mu   sync.Mutex
+	line []byte
+	done bool
+}
+
+func (f *firstLineWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	if !f.done {
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			f.line, f.done = append(f.line, p[:i]...), true
+		} else {
+			f.line = append(f.line, p...)
+		}
+	}
+	f.mu.Unlock()
+	return f.w.Write(p)
+}
+
+// firstLine returns the first line written, once it is complete.
+func (f *firstLineWriter) firstLine() (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return string(f.line), f.done
+}
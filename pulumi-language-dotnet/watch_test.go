@@ -0,0 +1,178 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer that can be written and read concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPluginWatcher(t *testing.T) {
+	t.Parallel()
+
+	project := t.TempDir()
+	source := filepath.Join(project, "Provider.cs")
+	require.NoError(t, os.WriteFile(filepath.Join(project, "Provider.csproj"), []byte("<Project />"), 0o600))
+	require.NoError(t, os.WriteFile(source, []byte("class Provider {}"), 0o600))
+
+	var stderr syncBuffer
+	builds := make(chan error)
+	runs := make(chan []string, 3)
+	w := &pluginWatcher{
+		project: project,
+		build: func(ctx context.Context) error {
+			select {
+			case err := <-builds:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+		checkSdk: func() error { return nil },
+		stdout:   &firstLineWriter{w: io.Discard},
+		stderr:   &stderr,
+		interval: 10 * time.Millisecond,
+	}
+	w.run = func(ctx context.Context, env []string) error {
+		fmt.Fprintf(w.stdout, "%d\n", 40000+len(runs))
+		runs <- env
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- w.watch(ctx) }()
+
+	builds <- nil
+	assert.Empty(t, <-runs)
+
+	// A change rebuilds, and a failed build is reported rather than ending the watch.
+	require.NoError(t, os.WriteFile(source, []byte("class Provider { oops }"), 0o600))
+	builds <- errors.New("Build exited with non-zero exit code: 1")
+	require.Eventually(t, func() bool {
+		return strings.Contains(stderr.String(), "waiting for changes to "+project)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, stderr.String(), project+" changed, rebuilding and restarting the plugin")
+
+	// Once fixed, the plugin is started again on the port it first reported.
+	require.NoError(t, os.WriteFile(source, []byte("class Provider { void Fixed() {} }"), 0o600))
+	builds <- nil
+	assert.Equal(t, []string{"PULUMI_DOTNET_PROVIDER_PORT=40000"}, <-runs)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop when its context was cancelled")
+	}
+}
+
+func TestPluginWatcherUnwatchable(t *testing.T) {
+	t.Parallel()
+
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, "Provider.csproj"),
+		[]byte(`<Project><Import Project="$(SolutionDir)shared.props" /></Project>`), 0o600))
+
+	built := false
+	w := &pluginWatcher{
+		project: project,
+		build: func(context.Context) error {
+			built = true
+			return nil
+		},
+	}
+	// Left to RunPlugin to run without watching.
+	var notWatchable *notWatchableError
+	require.ErrorAs(t, w.watch(t.Context()), &notWatchable)
+	assert.False(t, built)
+
+	// A plugin whose SDK can't be restarted isn't run at all.
+	require.NoError(t, os.WriteFile(filepath.Join(project, "Provider.csproj"), []byte("<Project />"), 0o600))
+	w.checkSdk = func() error { return errors.New("the watch option needs the Pulumi package 3.113.0 or later") }
+	w.run = func(context.Context, []string) error {
+		t.Fatal("the plugin should not run")
+		return nil
+	}
+	assert.EqualError(t, w.watch(t.Context()), "the watch option needs the Pulumi package 3.113.0 or later")
+	assert.True(t, built)
+}
+
+func TestCheckWatchSdk(t *testing.T) {
+	t.Parallel()
+
+	projectDir, _ := writeTestAssets(t, nil)
+	assert.EqualError(t, checkWatchSdk(projectDir), "the watch option needs the Pulumi package 3.113.0 or later, "+
+		projectDir+" uses 3.60.0")
+
+	projectDir, packageDir := writeTestAssets(t, func(assets map[string]any) {
+		libraries := assets["libraries"].(map[string]any)
+		delete(libraries, "Pulumi/3.60.0")
+		libraries["Pulumi/3.113.0"] = map[string]any{"type": "package", "path": "pulumi/3.113.0"}
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "pulumi", "3.113.0"), 0o700))
+	assert.NoError(t, checkWatchSdk(projectDir))
+}
+
+func TestFirstLineWriter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := &firstLineWriter{w: &out}
+	_, ok := w.firstLine()
+	assert.False(t, ok)
+
+	fmt.Fprint(w, "123")
+	_, ok = w.firstLine()
+	assert.False(t, ok)
+	fmt.Fprint(w, "45\nlater\n")
+	line, ok := w.firstLine()
+	assert.True(t, ok)
+	assert.Equal(t, "12345", line)
+	assert.Equal(t, "12345\nlater\n", out.String())
+}
@@ -0,0 +1,45 @@
+// Copyright 2026, Pulumi Corporation
+
+using System.IO;
+using Xunit;
+
+namespace Pulumi.Tests
+{
+    public class DeploymentConfigTests
+    {
+        [Fact]
+        public void ReadsConfigFile()
+        {
+            var path = Path.GetTempFileName();
+            try
+            {
+                File.WriteAllText(path, @"{
+                    ""config"": {""proj:config:name"": ""value"", ""proj:password"": ""hunter2""},
+                    ""secretKeys"": [""proj:password""]
+                }");
+
+                using var configFile = Deployment.ReadConfigFile(path);
+                Assert.NotNull(configFile);
+
+                var config = Deployment.ParseConfig(configFile!.RootElement.GetProperty("config"));
+                Assert.Equal(2, config.Count);
+                Assert.Equal("value", config["proj:name"]);
+                Assert.Equal("hunter2", config["proj:password"]);
+
+                var secretKeys = Deployment.ParseConfigSecretKeys(configFile.RootElement.GetProperty("secretKeys"));
+                Assert.Equal(new[] { "proj:password" }, secretKeys);
+            }
+            finally
+            {
+                File.Delete(path);
+            }
+        }
+
+        [Fact]
+        public void OlderLanguagePluginsWriteNoConfigFile()
+        {
+            Assert.Null(Deployment.ReadConfigFile(null));
+            Assert.Null(Deployment.ReadConfigFile(""));
+        }
+    }
+}
@@ -3,6 +3,7 @@
 using System;
 using System.Collections.Generic;
 using System.Collections.Immutable;
+using System.IO;
 using System.Text.Json;
 
 namespace Pulumi
@@ -19,6 +20,19 @@ namespace Pulumi
         /// </summary>
         private const string _configSecretKeysEnvKey = "PULUMI_CONFIG_SECRET_KEYS";
 
+        /// <summary>
+        /// The environment variable key that the language plugin uses to name a file holding both the configuration
+        /// values and the list of secret configuration keys, instead of setting them in the environment.
+        /// </summary>
+        private const string _configFileEnvKey = "PULUMI_CONFIG_FILE";
+
+        /// <summary>
+        /// The contents of the file named by <see cref="_configFileEnvKey"/>, or <see langword="null"/> if the
+        /// configuration is in the environment.
+        /// </summary>
+        private static readonly Lazy<JsonDocument?> _configFile =
+            new Lazy<JsonDocument?>(() => ReadConfigFile(Environment.GetEnvironmentVariable(_configFileEnvKey)));
+
         /// <summary>
         /// Returns a copy of the full config map.
         /// </summary>
@@ -61,16 +75,26 @@ namespace Pulumi
 
         private static ImmutableDictionary<string, string> ParseConfig()
         {
-            var parsedConfig = ImmutableDictionary.CreateBuilder<string, string>();
-            var envConfig = Environment.GetEnvironmentVariable(_configEnvKey);
+            if (_configFile.Value is { } configFile)
+            {
+                return ParseConfig(configFile.RootElement.GetProperty("config"));
+            }
 
+            var envConfig = Environment.GetEnvironmentVariable(_configEnvKey);
             if (envConfig != null)
             {
-                var envObject = JsonDocument.Parse(envConfig);
-                foreach (var prop in envObject.RootElement.EnumerateObject())
-                {
-                    parsedConfig[CleanKey(prop.Name)] = prop.Value.ToString();
-                }
+                return ParseConfig(JsonDocument.Parse(envConfig).RootElement);
+            }
+
+            return ImmutableDictionary<string, string>.Empty;
+        }
+
+        internal static ImmutableDictionary<string, string> ParseConfig(JsonElement config)
+        {
+            var parsedConfig = ImmutableDictionary.CreateBuilder<string, string>();
+            foreach (var prop in config.EnumerateObject())
+            {
+                parsedConfig[CleanKey(prop.Name)] = prop.Value.ToString();
             }
 
             return parsedConfig.ToImmutable();
@@ -78,21 +102,46 @@ namespace Pulumi
 
         private static ImmutableHashSet<string> ParseConfigSecretKeys()
         {
-            var parsedConfigSecretKeys = ImmutableHashSet.CreateBuilder<string>();
-            var envConfigSecretKeys = Environment.GetEnvironmentVariable(_configSecretKeysEnvKey);
+            if (_configFile.Value is { } configFile)
+            {
+                return ParseConfigSecretKeys(configFile.RootElement.GetProperty("secretKeys"));
+            }
 
+            var envConfigSecretKeys = Environment.GetEnvironmentVariable(_configSecretKeysEnvKey);
             if (envConfigSecretKeys != null)
             {
-                var envObject = JsonDocument.Parse(envConfigSecretKeys);
-                foreach (var element in envObject.RootElement.EnumerateArray())
-                {
-                    parsedConfigSecretKeys.Add(element.ToString());
-                }
+                return ParseConfigSecretKeys(JsonDocument.Parse(envConfigSecretKeys).RootElement);
+            }
+
+            return ImmutableHashSet<string>.Empty;
+        }
+
+        internal static ImmutableHashSet<string> ParseConfigSecretKeys(JsonElement secretKeys)
+        {
+            var parsedConfigSecretKeys = ImmutableHashSet.CreateBuilder<string>();
+            foreach (var element in secretKeys.EnumerateArray())
+            {
+                parsedConfigSecretKeys.Add(element.ToString());
             }
 
             return parsedConfigSecretKeys.ToImmutable();
         }
 
+        /// <summary>
+        /// Reads the file the language plugin wrote the configuration to, which looks like
+        /// <c>{"config": {"proj:key": "value"}, "secretKeys": ["proj:key"]}</c>. Older language plugins don't write
+        /// one, and <paramref name="path"/> is then <see langword="null"/> or empty.
+        /// </summary>
+        internal static JsonDocument? ReadConfigFile(string? path)
+        {
+            if (string.IsNullOrEmpty(path))
+            {
+                return null;
+            }
+
+            return JsonDocument.Parse(File.ReadAllBytes(path));
+        }
+
         /// <summary>
         /// CleanKey takes a configuration key, and if it is of the form "(string):config:(string)"
         /// removes the ":config:" portion. Previously, our keys always had the string ":config:" in
@@ -332,14 +332,7 @@ namespace Pulumi
             Func<Deployment> deploymentFactory,
             Func<IRunner, Task<T>> runAsync)
         {
-            if (Environment.GetEnvironmentVariable("PULUMI_ATTACH_DEBUGGER") == "true")
-            {
-                while (!System.Diagnostics.Debugger.IsAttached)
-                {
-                    // keep waiting until the debugger is attached
-                    await Task.Delay(100).ConfigureAwait(false);
-                }
-            }
+            await WaitForDebuggerAsync().ConfigureAwait(false);
 
             Instrumentation.Initialize();
             try
@@ -0,0 +1,56 @@
+// Copyright 2016-2026, Pulumi Corporation
+
+using System;
+using System.Diagnostics;
+using System.Globalization;
+using System.Threading.Tasks;
+
+namespace Pulumi
+{
+    public sealed partial class Deployment
+    {
+        /// <summary>
+        /// Blocks until a debugger is attached, if the language host asked for one.  <c>PULUMI_ATTACH_DEBUGGER</c>
+        /// means the engine is attaching it, <c>PULUMI_WAIT_FOR_DEBUGGER</c> that the user will attach one themselves,
+        /// so the process id is written to stderr for them.  <c>PULUMI_DEBUGGER_TIMEOUT</c>, in seconds, limits how
+        /// long to wait.
+        /// </summary>
+        internal static async Task WaitForDebuggerAsync()
+        {
+            var attach = Environment.GetEnvironmentVariable("PULUMI_ATTACH_DEBUGGER") == "true";
+            var wait = Environment.GetEnvironmentVariable("PULUMI_WAIT_FOR_DEBUGGER") == "true";
+            if (!(attach || wait) || Debugger.IsAttached)
+            {
+                return;
+            }
+
+            var processId = Environment.ProcessId;
+            if (wait)
+            {
+                await Console.Error.WriteLineAsync(
+                    $"Waiting for a debugger to attach to process {processId}").ConfigureAwait(false);
+            }
+
+            TimeSpan? timeout = null;
+            var timeoutValue = Environment.GetEnvironmentVariable("PULUMI_DEBUGGER_TIMEOUT");
+            if (double.TryParse(timeoutValue, NumberStyles.Float, CultureInfo.InvariantCulture, out var seconds) &&
+                seconds > 0)
+            {
+                timeout = TimeSpan.FromSeconds(seconds);
+            }
+
+            var stopwatch = Stopwatch.StartNew();
+            while (!Debugger.IsAttached)
+            {
+                if (timeout != null && stopwatch.Elapsed >= timeout)
+                {
+                    throw new InvalidOperationException(
+                        $"No debugger attached to process {processId} within {timeoutValue} seconds");
+                }
+
+                // keep waiting until the debugger is attached
+                await Task.Delay(100).ConfigureAwait(false);
+            }
+        }
+    }
+}
@@ -525,15 +525,7 @@ namespace Pulumi.Experimental.Provider
 
         public static async Task Serve(string[] args, string? version, Func<Experimental.IEngine, Provider> factory, System.Threading.CancellationToken cancellationToken)
         {
-            var value = System.Environment.GetEnvironmentVariable("PULUMI_ATTACH_DEBUGGER");
-            if (value != null && value == "true")
-            {
-                while (!System.Diagnostics.Debugger.IsAttached)
-                {
-                    // keep waiting until the debugger is attached
-                    System.Threading.Thread.Sleep(1);
-                }
-            }
+            await Deployment.WaitForDebuggerAsync().ConfigureAwait(false);
 
             // Construct the host. As part of this, we'll ensure that any deployment we run (e.g. as part of a Construct
             // call) is "non-signalling" -- that is, it will not be responsible for telling the engine managing the
@@ -580,13 +572,22 @@ namespace Pulumi.Experimental.Provider
 
             var engineAddress = GetEngineAddress(args);
 
+            // A provider restarted by the language host's watch mode has to listen on the port the engine already
+            // knows, otherwise any free port will do.
+            var port = 0;
+            var portValue = Environment.GetEnvironmentVariable("PULUMI_DOTNET_PROVIDER_PORT");
+            if (portValue != null && !int.TryParse(portValue, NumberStyles.None, CultureInfo.InvariantCulture, out port))
+            {
+                throw new ArgumentException($"PULUMI_DOTNET_PROVIDER_PORT must be a port number, not '{portValue}'");
+            }
+
             return Host.CreateDefaultBuilder()
                 .ConfigureWebHostDefaults(webBuilder =>
                 {
                     webBuilder
                         .ConfigureKestrel(kestrelOptions =>
                         {
-                            kestrelOptions.Listen(IPAddress.Loopback, 0, listenOptions =>
+                            kestrelOptions.Listen(IPAddress.Loopback, port, listenOptions =>
                             {
                                 listenOptions.Protocols = HttpProtocols.Http2;
                             });